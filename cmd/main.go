@@ -23,6 +23,18 @@ func main() {
 	slog.Debug("storage initialized")
 	defer st.Close()
 
+	// Подкоманда импорта или экспорта лент выполняется без запуска
+	// парсера и сервера.
+	if len(os.Args) > 1 && os.Args[1] == "opml" {
+		err := runOPML(st, os.Args[2:])
+		if err != nil {
+			slog.Error("opml command failed", logger.Err(err))
+			st.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Инициализируем и запускаем парсер RSS.
 	parser := parser.New(cfg, st)
 	slog.Debug("parser initialized")
@@ -36,6 +48,8 @@ func main() {
 	// Инициализируем сервер, объявляем обработчики API и запускаем сервер.
 	srv := server.New(cfg)
 	srv.API(st)
+	srv.Admin(st, parser, cfg.AdminToken)
+	srv.WebSub(parser)
 	srv.Middleware()
 	srv.Start()
 	slog.Info("Server started")
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"GoNews/internal/opml"
+	"GoNews/internal/parser"
+	"GoNews/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// cmdTime - максимальное время выполнения подкоманды.
+const cmdTime = time.Minute
+
+// errUsage - подкоманда вызвана с некорректными аргументами.
+var errUsage = errors.New("usage: news opml import|export <file>")
+
+// runOPML выполняет подкоманду импорта или экспорта списка лент в формате
+// OPML без запуска сервиса. Импортированные ленты начнут опрашиваться
+// при следующем запуске сервиса.
+func runOPML(st storage.DB, args []string) error {
+	const operation = "main.runOPML"
+
+	if len(args) != 2 {
+		return errUsage
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cmdTime)
+	defer cancel()
+
+	switch args[0] {
+	case "import":
+		f, err := os.Open(args[1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", operation, err)
+		}
+		defer f.Close()
+
+		feeds, err := opml.Parse(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", operation, err)
+		}
+		res := parser.Import(ctx, feeds, parser.Store(st))
+		return printResults(os.Stdout, res)
+
+	case "export":
+		feeds, err := st.Feeds(ctx)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("%s: %w", operation, err)
+		}
+		f, err := os.Create(args[1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", operation, err)
+		}
+		err = opml.Write(f, "GoNews feeds", feeds)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("%s: %w", operation, err)
+		}
+		err = f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", operation, err)
+		}
+		fmt.Printf("%d feeds exported to %s\n", len(feeds), args[1])
+		return nil
+	}
+	return errUsage
+}
+
+// printResults выводит результат импорта по каждой ленте в виде таблицы.
+func printResults(w io.Writer, res []parser.ImportResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESULT\tURL\tGROUP\tID/ERROR")
+	var n int
+	for _, r := range res {
+		if r.Accepted {
+			n++
+			fmt.Fprintf(tw, "accepted\t%s\t%s\t%s\n", r.URL, r.Group, r.ID)
+			continue
+		}
+		fmt.Fprintf(tw, "rejected\t%s\t%s\t%s\n", r.URL, r.Group, r.Error)
+	}
+	fmt.Fprintf(tw, "\n%d accepted, %d rejected\n", n, len(res)-n)
+	return tw.Flush()
+}
@@ -0,0 +1,186 @@
+// Пакет для приведения ссылок на посты к каноническому виду.
+package canonical
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultParams - параметры запроса, удаляемые из ссылок по умолчанию.
+// Параметр, оканчивающийся на "*", задает префикс имен параметров.
+var DefaultParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"yclid",
+	"msclkid",
+	"igshid",
+	"mc_cid",
+	"mc_eid",
+	"_openstat",
+	"_hsenc",
+	"_hsmi",
+}
+
+// Canonicalizer приводит ссылки к каноническому виду: удаляет
+// параметры отслеживания, нормализует схему, хост и путь, разрешает
+// относительные ссылки. Безопасен для конкурентного использования.
+type Canonicalizer struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+// std - канонизатор с параметрами по умолчанию, используется при
+// вызове методов у nil.
+var std = New(nil)
+
+// New - конструктор канонизатора. params - имена удаляемых параметров
+// запроса без учета регистра, которые удаляются вместе с DefaultParams.
+func New(params []string) *Canonicalizer {
+	all := make([]string, 0, len(DefaultParams)+len(params))
+	all = append(all, DefaultParams...)
+	all = append(all, params...)
+
+	c := &Canonicalizer{exact: make(map[string]bool)}
+	for _, p := range all {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case p == "" || p == "*":
+			continue
+		case strings.HasSuffix(p, "*"):
+			c.prefixes = append(c.prefixes, strings.TrimSuffix(p, "*"))
+		default:
+			c.exact[p] = true
+		}
+	}
+	return c
+}
+
+// URL возвращает каноническую ссылку. Относительная ссылка
+// разрешается относительно base. Схема и хост приводятся к нижнему
+// регистру, порт по умолчанию удаляется, пустой путь заменяется на "/",
+// точки и повторы "/" в пути схлопываются с сохранением завершающего
+// "/". Параметры отслеживания удаляются, остальные параметры
+// сортируются по имени. Ссылки, которые не удается разобрать, и ссылки
+// со схемой, отличной от http и https, возвращаются без изменений,
+// кроме удаления пробелов по краям.
+func (c *Canonicalizer) URL(raw, base string) string {
+	if c == nil {
+		c = std
+	}
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	if base != "" {
+		b, err := url.Parse(strings.TrimSpace(base))
+		if err == nil {
+			u = b.ResolveReference(u)
+		}
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return u.String()
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	// Путь с экранированными символами, например %2F, не меняется,
+	// чтобы не изменить его смысл.
+	if u.RawPath == "" {
+		u.Path = cleanPath(u.Path)
+	}
+
+	u.RawQuery = c.query(u.RawQuery)
+	return u.String()
+}
+
+// cleanPath нормализует путь ссылки, сохраняя завершающий "/".
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// query удаляет из строки запроса параметры отслеживания и сортирует
+// остальные параметры по имени. Порядок значений параметра
+// сохраняется.
+func (c *Canonicalizer) query(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+
+	for k := range values {
+		if c.tracking(k) {
+			delete(values, k)
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(k))
+			if v != "" {
+				b.WriteByte('=')
+				b.WriteString(url.QueryEscape(v))
+			}
+		}
+	}
+	return b.String()
+}
+
+// tracking сообщает, что параметр запроса является параметром
+// отслеживания.
+func (c *Canonicalizer) tracking(name string) bool {
+	name = strings.ToLower(name)
+	if c.exact[name] {
+		return true
+	}
+	for _, p := range c.prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
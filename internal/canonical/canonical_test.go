@@ -0,0 +1,111 @@
+// Пакет для приведения ссылок на посты к каноническому виду.
+package canonical
+
+import "testing"
+
+func TestCanonicalizer_URL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		params []string
+		raw    string
+		base   string
+		want   string
+	}{
+		{
+			name: "Tracking_Params",
+			raw:  "https://habr.com/ru/articles/831252/?utm_campaign=831252&utm_source=habrahabr&utm_medium=rss",
+			want: "https://habr.com/ru/articles/831252/",
+		},
+		{
+			name: "Other_Params_Sorted",
+			raw:  "https://example.com/news?id=2&fbclid=abc&UTM_Source=x&lang=ru",
+			want: "https://example.com/news?id=2&lang=ru",
+		},
+		{
+			name: "Scheme_And_Host",
+			raw:  "HTTPS://Example.COM:443",
+			want: "https://example.com/",
+		},
+		{
+			name: "Non_Default_Port",
+			raw:  "http://example.com:8080/a",
+			want: "http://example.com:8080/a",
+		},
+		{
+			name: "Path",
+			raw:  "https://example.com/blog//2024/./post/../go//",
+			want: "https://example.com/blog/2024/go/",
+		},
+		{
+			// Завершающий "/" сохраняется: "/a" и "/a/" могут быть
+			// разными страницами.
+			name: "Trailing_Slash",
+			raw:  "https://example.com/a/?id=1",
+			want: "https://example.com/a/?id=1",
+		},
+		{
+			name: "Root",
+			raw:  "https://example.com/",
+			want: "https://example.com/",
+		},
+		{
+			name: "Relative",
+			raw:  "/ru/articles/831252/?utm_source=rss",
+			base: "https://habr.com/ru/feed/",
+			want: "https://habr.com/ru/articles/831252/",
+		},
+		{
+			name: "Relative_Path",
+			raw:  "post.html",
+			base: "https://go.dev/blog/",
+			want: "https://go.dev/blog/post.html",
+		},
+		{
+			name: "Absolute_With_Base",
+			raw:  "https://go.dev/blog/go1.23",
+			base: "https://habr.com/",
+			want: "https://go.dev/blog/go1.23",
+		},
+		{
+			name: "Fragment",
+			raw:  "https://example.com/changelog?utm_medium=rss#v1.2",
+			want: "https://example.com/changelog#v1.2",
+		},
+		{
+			name: "Other_Scheme",
+			raw:  " mailto:news@example.com ",
+			want: "mailto:news@example.com",
+		},
+		{
+			name: "Empty",
+			raw:  "  ",
+			want: "",
+		},
+		{
+			// Параметры из конфига дополняют параметры по умолчанию.
+			name:   "Custom_Params",
+			params: []string{"ref", "from_*"},
+			raw:    "https://example.com/?ref=rss&from_feed=1&utm_source=x&msclkid=1&id=2",
+			want:   "https://example.com/?id=2",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := New(tt.params).URL(tt.raw, tt.base); got != tt.want {
+				t.Errorf("Canonicalizer.URL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Методы nil используют параметры по умолчанию.
+	var c *Canonicalizer
+	if got := c.URL("https://example.com?utm_source=x", ""); got != "https://example.com/" {
+		t.Errorf("Canonicalizer.URL() = %v, want %v", got, "https://example.com/")
+	}
+}
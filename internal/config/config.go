@@ -2,8 +2,11 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -11,12 +14,18 @@ import (
 
 // Структура конфига
 type Config struct {
-	RSSFeeds      []string      `yaml:"rss"`
-	RequestPeriod time.Duration `yaml:"request_period"`
-	StoragePath   string        `yaml:"storage_path"`
-	StorageUser   string        `yaml:"storage_user"`
-	StoragePasswd string        `yaml:"storage_passwd"`
-	HTTPServer    `yaml:"http_server"`
+	RSSFeeds       []Feed        `yaml:"rss"`
+	RequestPeriod  time.Duration `yaml:"request_period"`
+	MaxBodySize    int64         `yaml:"max_body_size"`
+	MaxItems       int           `yaml:"max_items"`
+	TrackingParams []string      `yaml:"tracking_params"`
+	WebSubCallback string        `yaml:"websub_callback"`
+	Tags           []TagRule     `yaml:"tags"`
+	StoragePath    string        `yaml:"storage_path"`
+	StorageUser    string        `yaml:"storage_user"`
+	StoragePasswd  string        `yaml:"storage_passwd"`
+	AdminToken     string        `yaml:"-"`
+	HTTPServer     `yaml:"http_server"`
 }
 type HTTPServer struct {
 	Address      string        `yaml:"address"`
@@ -25,9 +34,99 @@ type HTTPServer struct {
 	IdleTimeout  time.Duration `yaml:"idle_timeout"`
 }
 
+// Feed - настройки опроса одной RSS ленты. В файле конфига лента
+// задается либо строкой с адресом, либо объектом с полями ниже.
+// Нулевые Period и Timeout означают значения по умолчанию: общий
+// request_period и таймаут парсера.
+type Feed struct {
+	URL      string            `yaml:"url"`
+	Name     string            `yaml:"name"`
+	Group    string            `yaml:"group"`
+	Period   time.Duration     `yaml:"period"`
+	Timeout  time.Duration     `yaml:"timeout"`
+	Enabled  bool              `yaml:"enabled"`
+	FullText bool              `yaml:"full_text"`
+	Headers  map[string]string `yaml:"headers"`
+	Auth     Auth              `yaml:"auth"`
+}
+
+// Auth - учетные данные для доступа к ленте. Если указан Token,
+// используется схема Bearer, если Username - схема Basic. Значения
+// могут ссылаться на переменные окружения в виде ${NAME}, чтобы
+// не хранить секреты в файле конфига и в БД. Ссылки раскрываются
+// парсером при выполнении запроса.
+type Auth struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+}
+
+// TagRule - правило автоматической разметки постов тегом Tag. Пост
+// получает тег, если выполняется хотя бы одно из условий: в заголовке
+// или тексте поста есть одно из ключевых слов Keywords (целым словом
+// без учета регистра, "*" в конце слова задает префикс) или совпадение
+// с одним из регулярных выражений Patterns, пост получен из ленты
+// с адресом или названием из Sources или у поста в ленте есть одна
+// из категорий Categories.
+type TagRule struct {
+	Tag        string   `yaml:"tag"`
+	Keywords   []string `yaml:"keywords"`
+	Patterns   []string `yaml:"patterns"`
+	Sources    []string `yaml:"sources"`
+	Categories []string `yaml:"categories"`
+}
+
+// UnmarshalYAML декодирует правило разметки и проверяет, что у него
+// указан тег и регулярные выражения корректны.
+func (r *TagRule) UnmarshalYAML(value *yaml.Node) error {
+	// Псевдоним типа нужен, чтобы не вызывать UnmarshalYAML рекурсивно.
+	type rule TagRule
+	var v rule
+	err := value.Decode(&v)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(v.Tag) == "" {
+		return fmt.Errorf("line %d: tag is empty", value.Line)
+	}
+	for _, p := range v.Patterns {
+		_, err = regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("line %d: tag %q: %w", value.Line, v.Tag, err)
+		}
+	}
+
+	*r = TagRule(v)
+	return nil
+}
+
+// UnmarshalYAML декодирует ленту из строки с адресом или из объекта.
+// Лента, у которой не указано поле enabled, считается включенной.
+func (f *Feed) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*f = Feed{Enabled: true}
+		return value.Decode(&f.URL)
+	}
+
+	// Псевдоним типа нужен, чтобы не вызывать UnmarshalYAML рекурсивно.
+	type feed Feed
+	v := feed{Enabled: true}
+	err := value.Decode(&v)
+	if err != nil {
+		return err
+	}
+	if v.URL == "" {
+		return fmt.Errorf("line %d: feed url is empty", value.Line)
+	}
+
+	*f = Feed(v)
+	return nil
+}
+
 // MustLoad - инициализирует данные из конфиг файла. Путь к файлу берет из
 // переменной окружения NEWS_CONFIG_PATH, пароль для доступа к БД - из переменной
-// окружения MONGO_DB_PASSWD. Если не удается, то завершает приложение с ошибкой.
+// окружения MONGO_DB_PASSWD, токен доступа к API администратора - из переменной
+// окружения NEWS_ADMIN_TOKEN. Если не удается, то завершает приложение с ошибкой.
 func MustLoad() *Config {
 	configPath := os.Getenv("NEWS_CONFIG_PATH")
 	if configPath == "" {
@@ -54,5 +153,10 @@ func MustLoad() *Config {
 		log.Printf("MONGO_DB_PASSWD is not set\n")
 	}
 
+	cfg.AdminToken = os.Getenv("NEWS_ADMIN_TOKEN")
+	if cfg.AdminToken == "" {
+		log.Printf("NEWS_ADMIN_TOKEN is not set, admin API is disabled\n")
+	}
+
 	return &cfg
 }
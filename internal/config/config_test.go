@@ -4,7 +4,11 @@ package config
 
 import (
 	"GoNews/internal/logger"
+	"reflect"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // TestMustLoad позволяет проверить корректность указания пути
@@ -17,3 +21,134 @@ func TestMustLoad(t *testing.T) {
 		t.Fatalf("MustLoad() error = failed to load config")
 	}
 }
+
+// TestFeed_UnmarshalYAML позволяет проверить декодирование лент как
+// из строки с адресом, так и из объекта с настройками.
+func TestFeed_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Feed
+		wantErr bool
+	}{
+		{
+			name: "String",
+			data: `rss: ["https://habr.com/ru/rss/hub/go/all/"]`,
+			want: []Feed{{URL: "https://habr.com/ru/rss/hub/go/all/", Enabled: true}},
+		},
+		{
+			name: "Object",
+			data: `
+rss:
+  - url: "https://golangweekly.com/rss"
+    name: "Golang Weekly"
+    period: 24h
+    timeout: 15s
+    enabled: false
+    headers:
+      Accept: "application/rss+xml"
+    auth:
+      token: "${TEST_FEED_TOKEN}"
+`,
+			want: []Feed{{
+				URL:     "https://golangweekly.com/rss",
+				Name:    "Golang Weekly",
+				Period:  24 * time.Hour,
+				Timeout: 15 * time.Second,
+				Enabled: false,
+				Headers: map[string]string{"Accept": "application/rss+xml"},
+				Auth:    Auth{Token: "${TEST_FEED_TOKEN}"},
+			}},
+		},
+		{
+			name: "Mixed",
+			data: `
+rss:
+  - "https://habr.com/ru/rss/hub/go/all/"
+  - url: "https://golangweekly.com/rss"
+    auth:
+      username: "user"
+      password: "passwd"
+`,
+			want: []Feed{
+				{URL: "https://habr.com/ru/rss/hub/go/all/", Enabled: true},
+				{URL: "https://golangweekly.com/rss", Enabled: true, Auth: Auth{Username: "user", Password: "passwd"}},
+			},
+		},
+		{
+			name:    "No_URL",
+			data:    `rss: [{name: "Golang Weekly"}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := yaml.Unmarshal([]byte(tt.data), &cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Feed.UnmarshalYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(cfg.RSSFeeds, tt.want) {
+				t.Errorf("Feed.UnmarshalYAML() = %+v, want %+v", cfg.RSSFeeds, tt.want)
+			}
+		})
+	}
+}
+
+// TestTagRule_UnmarshalYAML позволяет проверить декодирование правил
+// разметки постов тегами.
+func TestTagRule_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []TagRule
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			data: `
+tags:
+  - tag: "concurrency"
+    keywords: ["goroutine*", "горутин*"]
+    patterns: ['(?i)\bchan(nel)?s?\b']
+    sources: ["https://golangweekly.com/rss"]
+    categories: ["Параллельное программирование"]
+`,
+			want: []TagRule{{
+				Tag:        "concurrency",
+				Keywords:   []string{"goroutine*", "горутин*"},
+				Patterns:   []string{`(?i)\bchan(nel)?s?\b`},
+				Sources:    []string{"https://golangweekly.com/rss"},
+				Categories: []string{"Параллельное программирование"},
+			}},
+		},
+		{
+			name:    "No_Tag",
+			data:    `tags: [{keywords: ["generics"]}]`,
+			wantErr: true,
+		},
+		{
+			name:    "Bad_Pattern",
+			data:    `tags: [{tag: "generics", patterns: ["(generic"]}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := yaml.Unmarshal([]byte(tt.data), &cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TagRule.UnmarshalYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(cfg.Tags, tt.want) {
+				t.Errorf("TagRule.UnmarshalYAML() = %+v, want %+v", cfg.Tags, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// Пакет для поиска RSS лент по адресу веб-сайта.
+package discover
+
+import (
+	"GoNews/internal/rss"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	ErrNoFeeds   = errors.New("no feeds found")
+	ErrBadStatus = errors.New("bad response status")
+)
+
+// maxPageSize - максимальный размер страницы, в которой ищутся ссылки
+// на ленты.
+const maxPageSize = 2 << 20
+
+// feedTypes - типы ссылок rel="alternate", указывающих на ленту.
+var feedTypes = map[string]string{
+	"application/rss+xml":   rss.FormatRSS,
+	"application/atom+xml":  rss.FormatAtom,
+	"application/rdf+xml":   rss.FormatRDF,
+	"application/feed+json": rss.FormatJSON,
+	"application/json":      rss.FormatJSON,
+}
+
+// paths - распространенные пути лент, которые проверяются, если на
+// странице нет ссылок на ленты.
+var paths = []string{"/feed", "/rss", "/rss.xml", "/feed.xml", "/atom.xml", "/index.xml", "/feed.json"}
+
+// Feed - найденная лента.
+type Feed struct {
+	URL    string `json:"url"`
+	Title  string `json:"title,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// Discover ищет ленты по адресу страницы. Если адрес сам указывает на
+// ленту, возвращает ее. Иначе возвращает ленты из ссылок
+// <link rel="alternate"> страницы, а если их нет - первую ленту,
+// найденную по распространенным путям на сайте. Если лент нет,
+// возвращает ErrNoFeeds.
+func Discover(ctx context.Context, client *http.Client, pageURL string) ([]Feed, error) {
+	const operation = "discover.Discover"
+
+	body, base, contentType, err := get(ctx, client, pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	if !isHTML(contentType) {
+		feed, ok := probe(body, contentType)
+		if ok {
+			feed.URL = base.String()
+			return []Feed{feed}, nil
+		}
+	}
+
+	feeds := links(body, contentType, base)
+	if len(feeds) > 0 {
+		return feeds, nil
+	}
+
+	for _, p := range paths {
+		u := base.ResolveReference(&url.URL{Path: p})
+		body, final, contentType, err := get(ctx, client, u.String())
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%s: %w", operation, ctx.Err())
+			}
+			continue
+		}
+		feed, ok := probe(body, contentType)
+		if ok {
+			feed.URL = final.String()
+			return []Feed{feed}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", operation, ErrNoFeeds)
+}
+
+// get выполняет запрос по адресу и возвращает тело ответа, итоговый
+// адрес после перенаправлений и тип содержимого.
+func get(ctx context.Context, client *http.Client, u string) ([]byte, *url.URL, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	req.Header.Set("Accept", "text/html, application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, "", fmt.Errorf("%w: %s", ErrBadStatus, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	final := req.URL
+	if resp.Request != nil && resp.Request.URL != nil {
+		final = resp.Request.URL
+	}
+	return body, final, resp.Header.Get("Content-Type"), nil
+}
+
+// probe проверяет, что тело ответа является лентой известного формата.
+// Для проверки достаточно заголовка канала и первого поста.
+func probe(body []byte, contentType string) (Feed, bool) {
+	dec, err := rss.NewDecoder(bytes.NewReader(body), contentType, rss.Limits{MaxItems: 1})
+	if err != nil {
+		return Feed{}, false
+	}
+	return Feed{Title: dec.Channel().Title, Format: dec.Format()}, true
+}
+
+// isHTML сообщает, что тип содержимого указывает на HTML страницу.
+func isHTML(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "text/html" || mt == "application/xhtml+xml"
+}
+
+// links возвращает ленты из ссылок <link rel="alternate"> страницы.
+// Относительные адреса разрешаются относительно адреса страницы или
+// элемента <base>. Повторы ссылок пропускаются.
+func links(page []byte, contentType string, base *url.URL) []Feed {
+	var feeds []Feed
+	seen := make(map[string]bool)
+
+	r, err := charset.NewReader(bytes.NewReader(page), contentType)
+	if err != nil {
+		r = bytes.NewReader(page)
+	}
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return feeds
+		case html.StartTagToken, html.SelfClosingTagToken:
+		default:
+			continue
+		}
+
+		tok := z.Token()
+		switch tok.DataAtom {
+		case atom.Body:
+			// Ссылки на ленты объявляются в заголовке страницы.
+			return feeds
+		case atom.Base:
+			if u, err := base.Parse(attr(tok, "href")); err == nil {
+				base = u
+			}
+		case atom.Link:
+			if !hasToken(attr(tok, "rel"), "alternate") {
+				continue
+			}
+			mt, _, _ := mime.ParseMediaType(attr(tok, "type"))
+			format, ok := feedTypes[mt]
+			if !ok {
+				continue
+			}
+			href := strings.TrimSpace(attr(tok, "href"))
+			if href == "" {
+				continue
+			}
+			u, err := base.Parse(href)
+			if err != nil || seen[u.String()] {
+				continue
+			}
+			seen[u.String()] = true
+			feeds = append(feeds, Feed{
+				URL:    u.String(),
+				Title:  strings.TrimSpace(attr(tok, "title")),
+				Format: format,
+			})
+		}
+	}
+}
+
+// attr возвращает значение атрибута элемента.
+func attr(tok html.Token, name string) string {
+	for _, a := range tok.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasToken сообщает, что список значений через пробел содержит
+// переданное значение без учета регистра.
+func hasToken(list, token string) bool {
+	for _, f := range strings.Fields(list) {
+		if strings.EqualFold(f, token) {
+			return true
+		}
+	}
+	return false
+}
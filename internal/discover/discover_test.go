@@ -0,0 +1,140 @@
+// Пакет для поиска RSS лент по адресу веб-сайта.
+package discover
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc - функция, реализующая интерфейс http.RoundTripper.
+// Мок из пакета mocks здесь не используется, так как пакет mocks сам
+// зависит от пакета discover.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// page - ответ тестового ресурса.
+type page struct {
+	contentType string
+	body        string
+}
+
+const (
+	testRSS = `<?xml version="1.0"?><rss version="2.0"><channel><title>Go</title>` +
+		`<item><title>Post</title></item><item><title>Post 2</title></item></channel></rss>`
+	testHTML = `<!DOCTYPE html><html><head>
+	<link rel="stylesheet" href="/style.css">
+	<link rel="alternate" type="application/rss+xml" title="RSS" href="/ru/rss/hubs/go/">
+	<link rel="Alternate" type="application/atom+xml; charset=utf-8" title="Atom" href="https://habr.com/atom.xml">
+	<link rel="alternate" type="application/rss+xml" href="/ru/rss/hubs/go/">
+	<link rel="alternate" hreflang="en" href="/en/hubs/go/">
+	</head><body><link rel="alternate" type="application/rss+xml" href="/body.xml"></body></html>`
+	testBaseHTML = `<html><head><base href="https://cdn.habr.com/feeds/">` +
+		`<link rel="alternate" type="application/rss+xml" href="go.xml"></head></html>`
+	testNoLinksHTML = `<html><head><title>Go</title></head><body></body></html>`
+)
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		url     string
+		pages   map[string]page
+		want    []Feed
+		wantErr error
+	}{
+		{
+			name: "Feed_URL",
+			url:  "https://habr.com/ru/rss/hubs/go/",
+			pages: map[string]page{
+				"https://habr.com/ru/rss/hubs/go/": {"application/rss+xml", testRSS},
+			},
+			want: []Feed{{URL: "https://habr.com/ru/rss/hubs/go/", Title: "Go", Format: "rss"}},
+		},
+		{
+			name: "HTML_Links",
+			url:  "https://habr.com/ru/hubs/go/",
+			pages: map[string]page{
+				"https://habr.com/ru/hubs/go/": {"text/html; charset=utf-8", testHTML},
+			},
+			want: []Feed{
+				{URL: "https://habr.com/ru/rss/hubs/go/", Title: "RSS", Format: "rss"},
+				{URL: "https://habr.com/atom.xml", Title: "Atom", Format: "atom"},
+			},
+		},
+		{
+			name: "Base_Element",
+			url:  "https://habr.com/ru/hubs/go/",
+			pages: map[string]page{
+				"https://habr.com/ru/hubs/go/": {"text/html", testBaseHTML},
+			},
+			want: []Feed{{URL: "https://cdn.habr.com/feeds/go.xml", Format: "rss"}},
+		},
+		{
+			name: "Common_Paths",
+			url:  "https://go.dev/blog/",
+			pages: map[string]page{
+				"https://go.dev/blog/":   {"text/html", testNoLinksHTML},
+				"https://go.dev/feed":    {"text/html", testNoLinksHTML},
+				"https://go.dev/rss.xml": {"application/xml", testRSS},
+			},
+			want: []Feed{{URL: "https://go.dev/rss.xml", Title: "Go", Format: "rss"}},
+		},
+		{
+			name: "No_Feeds",
+			url:  "https://go.dev/blog/",
+			pages: map[string]page{
+				"https://go.dev/blog/": {"text/html", testNoLinksHTML},
+			},
+			wantErr: ErrNoFeeds,
+		},
+		{
+			name:    "Bad_Status",
+			url:     "https://go.dev/blog/",
+			wantErr: ErrBadStatus,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Ресурсы, которых нет в pages, отвечают 404 Not Found.
+			rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				resp := &http.Response{
+					StatusCode: http.StatusNotFound,
+					Status:     "404 Not Found",
+					Body:       io.NopCloser(strings.NewReader("")),
+					Header:     make(http.Header),
+					Request:    req,
+				}
+				p, ok := tt.pages[req.URL.String()]
+				if ok {
+					resp.StatusCode = http.StatusOK
+					resp.Status = "200 OK"
+					resp.Body = io.NopCloser(strings.NewReader(p.body))
+					resp.Header.Set("Content-Type", p.contentType)
+				}
+				return resp, nil
+			})
+			client := &http.Client{Transport: rt}
+
+			got, err := Discover(context.Background(), client, tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Discover() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Discover() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,457 @@
+// Пакет для извлечения основного текста статьи со страницы сайта.
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	ErrNoContent = errors.New("no article content found")
+	ErrNotHTML   = errors.New("not an HTML page")
+	ErrBadStatus = errors.New("bad response status")
+)
+
+// maxPageSize - максимальный размер загружаемой страницы статьи.
+const maxPageSize = 2 << 20
+
+// minLength - минимальная длина текста статьи в символах. Более
+// короткий текст считается неудачным извлечением.
+const minLength = 250
+
+// minParagraph - минимальная длина текста абзаца, который учитывается
+// при оценке блоков страницы.
+const minParagraph = 25
+
+// Article - извлеченная статья: HTML основного блока страницы и его
+// текст с абзацами, разделенными пустой строкой.
+type Article struct {
+	HTML string
+	Text string
+}
+
+// skipped - элементы, которые не относятся к тексту статьи
+// и удаляются до оценки блоков.
+var skipped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Form:     true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Svg:      true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Canvas:   true,
+	atom.Template: true,
+	atom.Link:     true,
+	atom.Meta:     true,
+}
+
+// Классы и идентификаторы блоков, которые вероятно относятся или
+// не относятся к тексту статьи.
+var (
+	negative = regexp.MustCompile(`(?i)comment|sidebar|footer|footnote|menu|nav|share|social|related|advert|\bads?\b|promo|banner|cookie|popup|modal|subscribe|sponsor|widget|breadcrumb|pagination|author-info|tags`)
+	positive = regexp.MustCompile(`(?i)article|content|post|entry|body|main|text|story|blog`)
+)
+
+// blocks - элементы, между которыми в тексте статьи вставляется
+// перенос строки.
+var blocks = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Pre:        true,
+	atom.Blockquote: true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.Li:         true,
+	atom.Table:      true,
+	atom.Tr:         true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+}
+
+// Fetch загружает страницу по адресу link и извлекает из нее статью.
+func Fetch(ctx context.Context, client *http.Client, link string) (Article, error) {
+	const operation = "extract.Fetch"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return Article{}, fmt.Errorf("%s: %w", operation, err)
+	}
+	req.Header.Set("Accept", "text/html, application/xhtml+xml;q=0.9")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Article{}, fmt.Errorf("%s: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Article{}, fmt.Errorf("%s: %w: %s", operation, ErrBadStatus, resp.Status)
+	}
+
+	art, err := Extract(io.LimitReader(resp.Body, maxPageSize), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return Article{}, fmt.Errorf("%s: %w", operation, err)
+	}
+	return art, nil
+}
+
+// Extract извлекает статью из HTML страницы. Блоки страницы оцениваются
+// по количеству и длине абзацев текста с учетом плотности ссылок
+// и классов блоков, как в алгоритме Readability. Статьей считается
+// блок с наибольшей оценкой вместе с соседними блоками, близкими
+// к нему по оценке. Если текст статьи короче minLength, возвращает
+// ErrNoContent.
+func Extract(r io.Reader, contentType string) (Article, error) {
+	if contentType != "" {
+		mt, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mt != "text/html" && mt != "application/xhtml+xml") {
+			return Article{}, ErrNotHTML
+		}
+	}
+
+	cr, err := charset.NewReader(r, contentType)
+	if err != nil {
+		return Article{}, err
+	}
+	doc, err := html.Parse(cr)
+	if err != nil {
+		return Article{}, err
+	}
+
+	body := find(doc, atom.Body)
+	if body == nil {
+		return Article{}, ErrNoContent
+	}
+	prune(body)
+
+	nodes := candidate(body)
+	if len(nodes) == 0 {
+		return Article{}, ErrNoContent
+	}
+
+	var buf bytes.Buffer
+	var text strings.Builder
+	for _, n := range nodes {
+		err = html.Render(&buf, n)
+		if err != nil {
+			return Article{}, err
+		}
+		writeText(&text, n)
+	}
+
+	art := Article{HTML: buf.String(), Text: normalize(text.String())}
+	if utf8.RuneCountInString(art.Text) < minLength {
+		return Article{}, ErrNoContent
+	}
+	return art, nil
+}
+
+// find возвращает первый элемент с переданным тегом.
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if f := find(c, a); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// prune удаляет из дерева комментарии, скрытые элементы, элементы
+// из skipped и блоки, классы которых указывают на служебные части
+// страницы.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if unlikely(c) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+// unlikely сообщает, что узел не относится к тексту статьи.
+func unlikely(n *html.Node) bool {
+	switch n.Type {
+	case html.CommentNode:
+		return true
+	case html.ElementNode:
+	default:
+		return false
+	}
+
+	if skipped[n.DataAtom] {
+		return true
+	}
+	if _, ok := attr(n, "hidden"); ok {
+		return true
+	}
+	if v, _ := attr(n, "aria-hidden"); v == "true" {
+		return true
+	}
+	if v, _ := attr(n, "style"); strings.Contains(strings.ReplaceAll(v, " ", ""), "display:none") {
+		return true
+	}
+
+	switch n.DataAtom {
+	case atom.Article, atom.Main, atom.Body, atom.A:
+		return false
+	}
+	names := classes(n)
+	return negative.MatchString(names) && !positive.MatchString(names)
+}
+
+// candidate оценивает блоки страницы и возвращает блок с наибольшей
+// оценкой и подходящие соседние блоки в порядке следования.
+func candidate(body *html.Node) []*html.Node {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+
+	add := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initScore(n)
+			order = append(order, n)
+		}
+		scores[n] += score
+	}
+
+	walk(body, func(n *html.Node) {
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			return
+		}
+		text := innerText(n)
+		length := utf8.RuneCountInString(text)
+		if length < minParagraph {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，"))
+		score += min(float64(length)/100, 3)
+
+		add(n.Parent, score)
+		if n.Parent != nil {
+			add(n.Parent.Parent, score/2)
+		}
+	})
+
+	// Оценка блоков, состоящих в основном из ссылок, снижается.
+	var top *html.Node
+	for _, n := range order {
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+	if top == nil {
+		return nil
+	}
+	if top.Parent == nil {
+		return []*html.Node{top}
+	}
+
+	threshold := max(10, scores[top]*0.2)
+	var nodes []*html.Node
+	for s := top.Parent.FirstChild; s != nil; s = s.NextSibling {
+		if s == top {
+			nodes = append(nodes, s)
+			continue
+		}
+		if s.Type != html.ElementNode {
+			continue
+		}
+		if score, ok := scores[s]; ok && score >= threshold {
+			nodes = append(nodes, s)
+			continue
+		}
+		if s.DataAtom == atom.P {
+			text := innerText(s)
+			length := utf8.RuneCountInString(text)
+			density := linkDensity(s)
+			if (length > 80 && density < 0.25) ||
+				(length > 0 && density == 0 && strings.HasSuffix(text, ".")) {
+				nodes = append(nodes, s)
+			}
+		}
+	}
+	return nodes
+}
+
+// initScore возвращает начальную оценку блока по тегу и классам.
+func initScore(n *html.Node) float64 {
+	var score float64
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		score = 10
+	case atom.Div:
+		score = 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score = 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li:
+		score = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score = -5
+	}
+
+	names := classes(n)
+	if negative.MatchString(names) {
+		score -= 25
+	}
+	if positive.MatchString(names) {
+		score += 25
+	}
+	return score
+}
+
+// linkDensity возвращает долю текста блока, находящегося внутри ссылок.
+func linkDensity(n *html.Node) float64 {
+	length := utf8.RuneCountInString(innerText(n))
+	if length == 0 {
+		return 0
+	}
+	var links int
+	walk(n, func(c *html.Node) {
+		if c.DataAtom == atom.A {
+			links += utf8.RuneCountInString(innerText(c))
+		}
+	})
+	return min(float64(links)/float64(length), 1)
+}
+
+// walk вызывает fn для каждого элемента поддерева n, включая n.
+func walk(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+// innerText возвращает текст поддерева без учета разметки.
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// spaces - последовательности пробельных символов в тексте.
+var spaces = regexp.MustCompile(`\s+`)
+
+// writeText записывает текст поддерева, разделяя блоки переносом
+// строки. Пробелы в тексте схлопываются, кроме текста в <pre>.
+func writeText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if n.Parent != nil && n.Parent.DataAtom == atom.Pre {
+			b.WriteString(n.Data)
+			return
+		}
+		text := spaces.ReplaceAllString(n.Data, " ")
+		// Пробел в начале не нужен после пробела или переноса строки.
+		if str := b.String(); str == "" || strings.HasSuffix(str, " ") || strings.HasSuffix(str, "\n") {
+			text = strings.TrimLeft(text, " ")
+		}
+		b.WriteString(text)
+		return
+	case html.ElementNode:
+		if n.DataAtom == atom.Br {
+			b.WriteByte('\n')
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blocks[n.DataAtom]
+	if block {
+		b.WriteString("\n\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(b, c)
+	}
+	if block {
+		b.WriteString("\n\n")
+	}
+}
+
+// normalize удаляет пробелы в конце строк и схлопывает пустые строки,
+// оставляя между абзацами одну пустую строку.
+func normalize(s string) string {
+	var lines []string
+	var empty bool
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.TrimSpace(line) == "" {
+			empty = len(lines) > 0
+			continue
+		}
+		if empty {
+			lines = append(lines, "")
+			empty = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// classes возвращает значения атрибутов class и id элемента.
+func classes(n *html.Node) string {
+	class, _ := attr(n, "class")
+	id, _ := attr(n, "id")
+	return class + " " + id
+}
+
+// attr возвращает значение атрибута элемента и признак его наличия.
+func attr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,127 @@
+// Пакет для извлечения основного текста статьи со страницы сайта.
+package extract
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc - функция, реализующая интерфейс http.RoundTripper.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	page, err := os.ReadFile("testArticle.html")
+	if err != nil {
+		t.Fatalf("Extract() error = cannot read test HTML page")
+	}
+
+	tests := []struct {
+		name        string
+		page        string
+		contentType string
+		want        []string
+		notWant     []string
+		wantErr     error
+	}{
+		{
+			name:        "Article",
+			page:        string(page),
+			contentType: "text/html; charset=utf-8",
+			want: []string{
+				"Конкурентность в Go строится на двух примитивах",
+				"ключевое слово go перед вызовом функции",
+				"ch := make(chan int)\ngo func() {\n\tch <- 42\n}()",
+				"в Effective Go, а о планировщике",
+			},
+			notWant: []string{"Читают сейчас", "Моя лента", "Отличная статья", "Habr. Все права", "dataLayer"},
+		},
+		{
+			name:        "Too_Short",
+			page:        "<html><body><article><p>Короткая заметка без текста статьи.</p></article></body></html>",
+			contentType: "text/html",
+			wantErr:     ErrNoContent,
+		},
+		{
+			name:        "Not_HTML",
+			page:        `{"title": "Go"}`,
+			contentType: "application/json",
+			wantErr:     ErrNotHTML,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Extract(strings.NewReader(tt.page), tt.contentType)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Extract() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got.Text, w) {
+					t.Errorf("Extract() text = %q, want to contain %q", got.Text, w)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got.Text, w) || strings.Contains(got.HTML, w) {
+					t.Errorf("Extract() = %q, want not to contain %q", got.Text, w)
+				}
+			}
+			// Абзацы разделены пустой строкой.
+			if tt.wantErr == nil && !strings.Contains(got.Text, "система.\n\nЗапустить") {
+				t.Errorf("Extract() text = %q, want paragraphs", got.Text)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	page, err := os.ReadFile("testArticle.html")
+	if err != nil {
+		t.Fatalf("Fetch() error = cannot read test HTML page")
+	}
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(strings.NewReader(string(page))),
+			Header:     make(http.Header),
+			Request:    req,
+		}
+		resp.Header.Set("Content-Type", "text/html; charset=utf-8")
+		if req.URL.Path != "/ru/articles/831252/" {
+			resp.StatusCode = http.StatusNotFound
+			resp.Status = "404 Not Found"
+		}
+		return resp, nil
+	})
+	client := &http.Client{Transport: rt}
+
+	art, err := Fetch(context.Background(), client, "https://habr.com/ru/articles/831252/")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if !strings.HasPrefix(art.Text, "Конкурентность в Go") {
+		t.Errorf("Fetch() text = %q", art.Text)
+	}
+
+	_, err = Fetch(context.Background(), client, "https://habr.com/ru/articles/1/")
+	if !errors.Is(err, ErrBadStatus) {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrBadStatus)
+	}
+}
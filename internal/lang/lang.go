@@ -0,0 +1,192 @@
+// Пакет для определения языка текста по n-граммам символов.
+package lang
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Алфавиты, для которых есть профили языков.
+const (
+	cyrillic = "cyrillic"
+	latin    = "latin"
+)
+
+// maxLetters - максимальное число букв текста, по которым определяется
+// язык. Остальной текст не анализируется.
+const maxLetters = 2000
+
+// minLetters - минимальное число букв текста. Язык более короткого
+// текста не определяется, возвращается язык по умолчанию.
+const minLetters = 20
+
+// minScore - минимальная оценка языка. Если оценки всех языков ниже,
+// язык считается неизвестным и возвращается язык по умолчанию.
+const minScore = 0.05
+
+// priorBonus - множитель оценки языка по умолчанию. Позволяет выбрать
+// язык по умолчанию, если оценки близких языков почти равны.
+const priorBonus = 1.2
+
+// ranks - ранги триграмм в профилях языков по алфавитам.
+var ranks = func() map[string]map[string]map[string]int {
+	res := make(map[string]map[string]map[string]int, len(profiles))
+	for script, langs := range profiles {
+		res[script] = make(map[string]map[string]int, len(langs))
+		for code, profile := range langs {
+			r := make(map[string]int)
+			for _, t := range strings.Fields(profile) {
+				if _, ok := r[t]; !ok {
+					r[t] = len(r)
+				}
+			}
+			res[script][code] = r
+		}
+	}
+	return res
+}()
+
+// Code приводит код языка, например из элемента language канала
+// RSS, к коду ISO 639 без региона в нижнем регистре: "ru-RU" и "RU_ru"
+// преобразуются в "ru". Для некорректного кода возвращает пустую
+// строку.
+func Code(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if len(tag) < 2 || len(tag) > 3 {
+		return ""
+	}
+	for _, r := range tag {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	return tag
+}
+
+// Detect возвращает код языка ISO 639-1 текста, определенный
+// по частым триграммам символов: ru, uk, en, de, fr или es. prior -
+// язык по умолчанию, например из элемента language канала RSS. Он
+// получает преимущество перед другими языками того же алфавита
+// и возвращается, если текст слишком короткий или его язык
+// не поддерживается. Если язык определить не удалось и prior не задан,
+// возвращает пустую строку.
+func Detect(text, prior string) string {
+	prior = Code(prior)
+
+	counts, script, letters := trigrams(text)
+	if letters < minLetters || script == "" {
+		return prior
+	}
+
+	var total int
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return prior
+	}
+
+	best, bestScore := "", 0.0
+	codes := make([]string, 0, len(ranks[script]))
+	for code := range ranks[script] {
+		codes = append(codes, code)
+	}
+	// Порядок обхода фиксирован, чтобы результат при равных оценках
+	// не зависел от порядка обхода карты.
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		profile := ranks[script][code]
+		var score float64
+		for t, n := range counts {
+			rank, ok := profile[t]
+			if !ok {
+				continue
+			}
+			// Вес триграммы уменьшается с рангом от 1 до 0.5.
+			score += float64(n) * (1 - float64(rank)/float64(2*len(profile)))
+		}
+		score /= float64(total)
+		if code == prior {
+			score *= priorBonus
+		}
+		if score > bestScore {
+			best, bestScore = code, score
+		}
+	}
+
+	if bestScore < minScore {
+		return prior
+	}
+	return best
+}
+
+// trigrams возвращает количество триграмм символов в словах текста,
+// записанных преобладающим алфавитом, этот алфавит и число букв.
+// Слова приводятся к нижнему регистру и дополняются символом "_"
+// по краям. Слова других алфавитов, например названия и код в русском
+// тексте, не учитываются. Пустой алфавит означает, что в тексте
+// преобладают буквы алфавита без профилей языков.
+func trigrams(text string) (map[string]int, string, int) {
+	counts := map[string]map[string]int{
+		cyrillic: make(map[string]int),
+		latin:    make(map[string]int),
+	}
+	var letters, cyr, lat, other int
+
+	word := []rune{'_'}
+	var wordScript string
+	flush := func() {
+		if len(word) > 1 && wordScript != "" {
+			word = append(word, '_')
+			for i := 0; i+3 <= len(word); i++ {
+				counts[wordScript][string(word[i:i+3])]++
+			}
+		}
+		word = word[:1]
+		wordScript = ""
+	}
+
+	for _, r := range text {
+		if letters >= maxLetters {
+			break
+		}
+		if !unicode.IsLetter(r) {
+			flush()
+			continue
+		}
+		letters++
+		var script string
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			script = cyrillic
+			cyr++
+		case unicode.Is(unicode.Latin, r):
+			script = latin
+			lat++
+		default:
+			other++
+		}
+		// Слово относится к алфавиту первой буквы, слова со смешанными
+		// алфавитами не учитываются.
+		if len(word) == 1 {
+			wordScript = script
+		} else if wordScript != script {
+			wordScript = ""
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	flush()
+
+	switch {
+	case cyr > lat && cyr > other:
+		return counts[cyrillic], cyrillic, letters
+	case lat > cyr && lat > other:
+		return counts[latin], latin, letters
+	}
+	return nil, "", letters
+}
@@ -0,0 +1,102 @@
+// Пакет для определения языка текста по n-граммам символов.
+package lang
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		text  string
+		prior string
+		want  string
+	}{
+		{
+			name: "Russian",
+			text: "Я пишу на Go несколько лет, в Каруне многие вещи сделаны на нём; язык мне нравится своей простотой, незамысловатой прямолинейностью и приличной эффективностью.",
+			want: "ru",
+		},
+		{
+			name: "Russian_With_Code",
+			text: "Но сорян, к бесконечным if err != nil я до конца привыкнуть так и не смог. Да-да, я знаю все аргументы: явное лучше неявного.",
+			want: "ru",
+		},
+		{
+			name: "Ukrainian",
+			text: "Сьогодні ми розповімо про нову версію мови програмування Go, яка з'явилася цього тижня. Вона містить багато покращень для розробників.",
+			want: "uk",
+		},
+		{
+			name:  "English",
+			text:  "Today the Go team is happy to release Go 1.23, which includes range over function types and several improvements to the toolchain.",
+			prior: "ru-RU",
+			want:  "en",
+		},
+		{
+			name: "German",
+			text: "Die neue Version der Programmiersprache ist seit heute verfügbar und bringt viele Verbesserungen für Entwickler mit sich.",
+			want: "de",
+		},
+		{
+			name: "French",
+			text: "La nouvelle version du langage de programmation est disponible depuis aujourd'hui et apporte de nombreuses améliorations pour les développeurs.",
+			want: "fr",
+		},
+		{
+			name: "Spanish",
+			text: "La nueva versión del lenguaje de programación está disponible desde hoy y trae muchas mejoras para los desarrolladores.",
+			want: "es",
+		},
+		{
+			name:  "Short_Text_Prior",
+			text:  "Go 1.23",
+			prior: "en-us",
+			want:  "en",
+		},
+		{
+			name: "Short_Text_Without_Prior",
+			text: "Go 1.23",
+			want: "",
+		},
+		{
+			name:  "Unsupported_Script_Prior",
+			text:  "今日、Go チームは Go 1.23 のリリースを発表できることを嬉しく思います。",
+			prior: "ja",
+			want:  "ja",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Detect(tt.text, tt.prior); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{tag: "ru", want: "ru"},
+		{tag: " ru-RU ", want: "ru"},
+		{tag: "EN_us", want: "en"},
+		{tag: "rus", want: "rus"},
+		{tag: "", want: ""},
+		{tag: "русский", want: ""},
+		{tag: "x", want: ""},
+	}
+	for _, tt := range tests {
+		if got := Code(tt.tag); got != tt.want {
+			t.Errorf("Code(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Пакет для определения языка текста по n-граммам символов.
+package lang
+
+// profiles - профили поддерживаемых языков: самые частые триграммы
+// символов в порядке убывания частоты. Символ "_" обозначает границу
+// слова. Профили сгруппированы по алфавиту, сравниваются только
+// профили алфавита, преобладающего в тексте.
+var profiles = map[string]map[string]string{
+	cyrillic: {
+		"ru": `_пр _по ого ост _на ени ств ния ть_ ова _не _в_ про ани ет_
+			_и_ ия_ ст_ ных на_ ые_ _ко ие_ ий_ ой_ ый_ ая_ ых_ то_ ов_
+			_с_ ско ель тор при _за ся_ его _до ать пол _от _ра
+			ом_ ми_ ли_ ла_ _об ред как _ка _чт что _бы был _со
+			ыва ены ото раз _эт это ции ция ком _вы ым_ ами
+			тел ьно сть ест _ме ере _ис ую_ уже _уж _те все _вс ещё`,
+		"uk": `_пр ння _по _на ня_ ого ти_ _ві від ськ _за ого _і_ _та
+			та_ ії_ ста ний _не _що що_ ова енн ні_ ьно _ук укр
+			кра аїн їни ини _ст ть_ ає_ ють _як як_ _бу ува ів_ их_ ими
+			_ми ся_ _до ий_ ої_ ою_ _є_ єть _її _їх ці_ ист
+			_зд ції ція _де ієн _ці цей _ал але або _аб ади _вс усі`,
+	},
+	latin: {
+		"en": `_th the he_ _an nd_ and _of of_ ed_ _to to_ _in ing ng_
+			in_ er_ ion tio on_ is_ _a_ _is es_ re_ at_ _co ent for _fo
+			or_ _be hat tha _wh ter _re _ha as_ _it it_ al_ ati ly_ _wi
+			wit ith his ver all _on _pr nt_ ate ons _st st_ ere her _se
+			_wa was you _yo ou_ are _ar not _no ot_ _we we_ ers men _ca
+			_ne new _us use _ho how _so ld_ _de _ma _fr fro rom om_`,
+		"de": `_de der die _di en_ er_ ich ein _ei sch und _un nd_ ie_
+			che cht _be ng_ ung _ge gen ine den _da das as_ ten _zu zu_
+			ch_ in_ _in ter ste ver _ve ier te_ es_ ht_ eit ist _is st_
+			auf _au mit _mi it_ ber ede nde _wi _so sie _si nen lic ege
+			_ni nic _fü für ür_ _wu wer _we ach aus _al als _ab
+			_mö _üb übe _vo von on_ _ke _im im_ ere _ic`,
+		"fr": `_de de_ es_ _le le_ ent _la la_ nt_ ion tio on_ _et et_
+			_qu que ue_ _pa les re_ ur_ _co des men _un une ne_ _po our
+			pou ait _en en_ ans dan _da _pr _se par est _es st_ eme lle
+			ell ire ais ous _ce _il il_ qui été _ét té_ _à_ _a_ ée_
+			és_ _du du_ au_ _au aux _ne _pl plu lus sur _su _so son _on
+			ont _sa _vo tre _tr _fa _pe _ma _re _mê _où _ça _ço eux`,
+		"es": `_de de_ _la la_ os_ _el el_ en_ _en es_ ión ció _co que
+			_qu ue_ as_ ent _lo los _se _pr ado do_ _es _un un_ ar_ con
+			ra_ _po por or_ er_ nte _ca ien sta _al par _pa ara aci _y_
+			ida res ica mos _su est _ha _má más _me _ma las del
+			_no no_ ón_ _pe ero _so _ta _tr ndo _fu uer
+			ñol año _añ _ot otr _si _ti ier _cu _ll _ví amb bié`,
+	},
+}
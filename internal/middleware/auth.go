@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// BearerAuth пропускает запрос дальше, только если в заголовке
+// Authorization передан токен token по схеме Bearer. Токены сравниваются
+// за постоянное время, чтобы не раскрывать их через время ответа.
+func BearerAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		got, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			slog.Warn("unauthorized request",
+				slog.String("uri", r.RequestURI),
+				slog.String("remote_address", r.RemoteAddr),
+				slog.String("request_id", GetReqID(r.Context())),
+			)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerAuth(t *testing.T) {
+	var fn http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{
+			name:   "OK",
+			token:  "secret",
+			header: "Bearer secret",
+			want:   http.StatusNoContent,
+		},
+		{
+			name:   "Wrong_Token",
+			token:  "secret",
+			header: "Bearer public",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "Wrong_Scheme",
+			token:  "secret",
+			header: "Basic secret",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "No_Header",
+			token:  "secret",
+			header: "",
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "Empty_Token",
+			token:  "",
+			header: "Bearer ",
+			want:   http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+
+			BearerAuth(tt.token, fn).ServeHTTP(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("BearerAuth error, status code = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Пакет для вычисления сигнатур MinHash, по которым находятся тексты
+// об одном и том же, в том числе пересказанные другими словами.
+package minhash
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// Size - число значений сигнатуры. Погрешность оценки сходства
+// по сигнатуре около 0.04.
+const Size = 128
+
+// Rows - число значений сигнатуры в одной части для поиска кандидатов.
+const Rows = 2
+
+// Bands - число частей сигнатуры. Тексты со сходством Threshold имеют
+// хотя бы одну общую часть с вероятностью около 0.98.
+const Bands = Size / Rows
+
+// Threshold - минимальное сходство текстов об одном и том же.
+// Пересказы одной новости разными изданиями имеют сходство около
+// 0.3-0.5, разные новости на одну тему - не больше 0.2.
+const Threshold = 0.25
+
+// leadWords - число слов текста, по которым вычисляется сигнатура.
+// Издания пересказывают прежде всего начало новости, а длинный текст
+// статьи размывал бы сходство.
+const leadWords = 60
+
+// stemLen - число первых букв слова, по которым сравниваются слова.
+// Грубо отбрасывает окончания, чтобы "итераторы" и "итераторами"
+// совпадали.
+const stemLen = 5
+
+// stopWords - частые слова, не влияющие на сходство текстов.
+var stopWords = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(`
+		the an and or of to in on for with is are was were be been has
+		have had it its this that these those by as at from into out
+		new now also which who what how why not but than then there
+		their they we you our your can will would should may more most
+		over after before about such all any each other some very just
+		only via
+		и в во на не что как по со ко за из от до для об но же ли бы это
+		этот эта эти то та те был была были быть его ее её их он она они
+		мы вы при без под над также уже еще ещё или которые который
+		которая которое чтобы так все всё можно теперь новый новые новая`) {
+		m[w] = true
+	}
+	return m
+}()
+
+// seeds - коэффициенты хеш-функций сигнатуры. Фиксированы, чтобы
+// сигнатуры, записанные в БД, оставались сравнимыми.
+var seeds = func() [Size][2]uint64 {
+	var s [Size][2]uint64
+	x := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		// splitmix64
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for i := range s {
+		s[i] = [2]uint64{next() | 1, next()}
+	}
+	return s
+}()
+
+// Signature возвращает сигнатуру MinHash множества слов заголовка
+// и первых leadWords слов текста. Слова приводятся к нижнему регистру
+// и обрезаются до stemLen букв, частые слова отбрасываются, номера
+// версий вида "1.23" сохраняются целиком. Для текста без слов
+// возвращает nil.
+func Signature(title, content string) []uint32 {
+	words := make(map[string]bool)
+	for _, w := range tokens(title, -1) {
+		words[w] = true
+	}
+	for _, w := range tokens(content, leadWords) {
+		words[w] = true
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	sig := make([]uint32, Size)
+	for i := range sig {
+		sig[i] = ^uint32(0)
+	}
+	for w := range words {
+		h := fnv.New64a()
+		h.Write([]byte(w))
+		sum := h.Sum64()
+		for i, s := range seeds {
+			if v := uint32((sum*s[0] + s[1]) >> 32); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// Similarity возвращает оценку сходства Жаккара множеств слов текстов
+// по их сигнатурам: долю совпадающих значений. Для пустых сигнатур
+// и сигнатур разного размера возвращает 0.
+func Similarity(a, b []uint32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var n int
+	for i := range a {
+		if a[i] == b[i] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(a))
+}
+
+// Similar сообщает, что тексты с сигнатурами a и b об одном и том же.
+func Similar(a, b []uint32) bool {
+	return Similarity(a, b) >= Threshold
+}
+
+// BandKeys возвращает ключи частей сигнатуры: номер части в старших
+// 32 битах и хеш значений части в младших. У похожих текстов с большой
+// вероятностью совпадает хотя бы один ключ, поэтому кандидатов
+// в похожие тексты можно искать по индексу ключей. Для пустой
+// сигнатуры возвращает nil.
+func BandKeys(sig []uint32) []int64 {
+	if len(sig) != Size {
+		return nil
+	}
+	keys := make([]int64, Bands)
+	for i := range keys {
+		h := fnv.New32a()
+		for _, v := range sig[i*Rows : (i+1)*Rows] {
+			h.Write([]byte{byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24)})
+		}
+		keys[i] = int64(i)<<32 | int64(h.Sum32())
+	}
+	return keys
+}
+
+// tokens возвращает не больше limit слов текста для сигнатуры,
+// отрицательный limit не ограничивает число слов.
+func tokens(text string, limit int) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		w := string(cur)
+		cur = cur[:0]
+		if len([]rune(w)) < 2 || stopWords[w] {
+			return
+		}
+		if r := []rune(w); len(r) > stemLen && strings.IndexFunc(w, unicode.IsDigit) < 0 {
+			w = string(r[:stemLen])
+		}
+		words = append(words, w)
+	}
+
+	runes := []rune(strings.ToLower(text))
+	for i, r := range runes {
+		if limit >= 0 && len(words) >= limit {
+			return words
+		}
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			cur = append(cur, r)
+		// Точка между цифрами - часть номера версии.
+		case r == '.' && len(cur) > 0 && unicode.IsDigit(cur[len(cur)-1]) &&
+			i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			cur = append(cur, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	if limit >= 0 && len(words) > limit {
+		words = words[:limit]
+	}
+	return words
+}
@@ -0,0 +1,182 @@
+package minhash
+
+import (
+	"reflect"
+	"testing"
+)
+
+// story - пост ленты.
+type story struct {
+	title   string
+	content string
+}
+
+func TestSimilar(t *testing.T) {
+	t.Parallel()
+
+	var (
+		go123 = story{"Go 1.23 is released", "The Go team has released Go 1.23, which adds range-over-func iterators, the new iter and unique packages, and opt-in toolchain telemetry. Timer changes and improvements to the go vet tool are also included."}
+		k8s   = story{"Kubernetes 1.31 released: Elli", "Kubernetes v1.31 ships 45 enhancements, including AppArmor support going GA, nftables backend for kube-proxy in beta and removal of in-tree cloud providers."}
+		goRu  = story{"Вышел Go 1.23", "Команда Go выпустила версию 1.23. В релизе появились итераторы по функциям в range, пакеты iter и unique, а также телеметрия инструментов, которую можно включить по желанию."}
+		cs    = story{"CrowdStrike update crashes Windows machines worldwide", "A faulty content update for CrowdStrike Falcon sensor caused millions of Windows computers to crash with a blue screen, grounding flights and disrupting banks and hospitals."}
+		pg    = story{"PostgreSQL 17 released", "The PostgreSQL Global Development Group announced PostgreSQL 17 with a new memory management system for vacuum, incremental backups in pg_basebackup and improved JSON support including JSON_TABLE."}
+	)
+
+	// Пары постов разных изданий: пересказы одной новости своими словами
+	// и разные новости на одну тему.
+	tests := []struct {
+		name    string
+		a       story
+		b       story
+		similar bool
+	}{
+		{
+			name:    "Same_Go_release",
+			a:       go123,
+			b:       story{"Go 1.23 arrives with iterators and telemetry", "Version 1.23 of the Go programming language is out. Headline features include support for ranging over function iterators, new standard library packages iter and unique, and optional telemetry collection for the toolchain."},
+			similar: true,
+		},
+		{
+			name:    "Same_Kubernetes_release",
+			a:       k8s,
+			b:       story{"What's new in Kubernetes 1.31", "The Kubernetes project announced version 1.31, code-named Elli. The release contains 45 enhancements: AppArmor support is now generally available, the nftables kube-proxy backend graduates to beta, and in-tree cloud provider code has been removed."},
+			similar: true,
+		},
+		{
+			// Слова с разными окончаниями совпадают.
+			name:    "Same_Go_release_ru",
+			a:       goRu,
+			b:       story{"Релиз Go 1.23: итераторы и телеметрия", "Состоялся выпуск языка программирования Go 1.23. Главные изменения — поддержка range по функциям-итераторам, новые пакеты стандартной библиотеки iter и unique и опциональная телеметрия тулчейна."},
+			similar: true,
+		},
+		{
+			name:    "Same_outage",
+			a:       cs,
+			b:       story{"Global IT outage traced to CrowdStrike Falcon update", "Airlines, banks and hospitals were hit after a defective CrowdStrike Falcon sensor update sent Windows PCs into blue screen crash loops. Microsoft estimates 8.5 million devices were affected."},
+			similar: true,
+		},
+		{
+			name:    "Same_outage_ru",
+			a:       story{"Сбой CrowdStrike вывел из строя компьютеры на Windows по всему миру", "Ошибочное обновление агента CrowdStrike Falcon привело к синему экрану смерти на миллионах компьютеров Windows, из-за чего пострадали авиакомпании, банки и больницы."},
+			b:       story{"Обновление CrowdStrike Falcon обрушило Windows", "Авиакомпании, банки и больницы столкнулись с массовыми сбоями: дефектное обновление сенсора CrowdStrike Falcon отправило компьютеры на Windows в циклическую перезагрузку с синим экраном."},
+			similar: true,
+		},
+		{
+			name:    "Same_PostgreSQL_release",
+			a:       pg,
+			b:       story{"PostgreSQL 17 brings incremental backup and JSON_TABLE", "PostgreSQL 17 is now available. The release adds incremental backups to pg_basebackup, reduces vacuum memory usage with a new internal structure and adds SQL/JSON features such as JSON_TABLE."},
+			similar: true,
+		},
+		{
+			// Номера версий различаются.
+			name:    "Other_Go_release",
+			a:       go123,
+			b:       story{"Go 1.22 is released", "Go 1.22 changes for loop variable semantics so that each iteration has its own variable, and adds enhanced routing patterns to net/http ServeMux."},
+			similar: false,
+		},
+		{
+			name:    "Other_release",
+			a:       go123,
+			b:       k8s,
+			similar: false,
+		},
+		{
+			name:    "Same_topic_ru",
+			a:       goRu,
+			b:       story{"Горутины и каналы в Go: разбираемся с конкурентностью", "В статье разбираем, как устроены горутины, зачем нужны каналы и как избежать гонок данных с помощью мьютексов и пакета sync."},
+			similar: false,
+		},
+		{
+			// Одинаково построенные новости о разных событиях.
+			name:    "Same_template_ru",
+			a:       goRu,
+			b:       story{"Вышел Rust 1.80", "Команда Rust выпустила версию 1.80. В релизе стабилизированы LazyCell и LazyLock, а также исключающие диапазоны в шаблонах."},
+			similar: false,
+		},
+		{
+			name:    "Other_database_release",
+			a:       pg,
+			b:       story{"MySQL 9.0 released", "Oracle announced MySQL 9.0 with JavaScript stored programs, vector data type support and EXPLAIN ANALYZE output in JSON format."},
+			similar: false,
+		},
+		{
+			name:    "Same_vendor",
+			a:       cs,
+			b:       story{"Microsoft Patch Tuesday fixes 142 vulnerabilities", "Microsoft's July update fixes 142 flaws in Windows and Office, including two zero-days actively exploited in the wild."},
+			similar: false,
+		},
+		{
+			name:    "Release_candidate",
+			a:       go123,
+			b:       story{"Go 1.23 release candidate 1 is available", "The first release candidate of Go 1.23 is available for testing. Please report any issues you find on the issue tracker."},
+			similar: false,
+		},
+		{
+			// Сигнатура текста без слов не похожа ни на одну другую.
+			name:    "Empty",
+			a:       story{" ... ", ""},
+			b:       story{" ... ", ""},
+			similar: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, b := Signature(tt.a.title, tt.a.content), Signature(tt.b.title, tt.b.content)
+			if got := Similar(a, b); got != tt.similar {
+				t.Errorf("Similar() = %v, want %v, similarity %.3f", got, tt.similar, Similarity(a, b))
+			}
+		})
+	}
+}
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	got := tokens("Вышел Go 1.23: итераторы и ИТЕРАТОРАМИ в range.", -1)
+	want := []string{"вышел", "go", "1.23", "итера", "итера", "range"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokens() = %q, want %q", got, want)
+	}
+
+	if got := tokens("one two three four", 2); len(got) != 2 {
+		t.Errorf("tokens() = %q, want 2 words", got)
+	}
+}
+
+func TestBandKeys(t *testing.T) {
+	t.Parallel()
+
+	a := Signature("Go 1.23 is released", "The Go team has released Go 1.23 with iterators.")
+	b := Signature("Go 1.23 arrives with iterators", "Version 1.23 of the Go programming language is out.")
+
+	keys := BandKeys(a)
+	if len(keys) != Bands {
+		t.Fatalf("len(BandKeys()) = %d, want %d", len(keys), Bands)
+	}
+	// Номер части хранится в старших битах, поэтому ключи разных частей
+	// не совпадают.
+	for i, k := range keys {
+		if int(k>>32) != i {
+			t.Errorf("BandKeys()[%d] part = %d, want %d", i, k>>32, i)
+		}
+	}
+
+	// Похожие тексты имеют общую часть.
+	common := false
+	for i, k := range BandKeys(b) {
+		if k == keys[i] {
+			common = true
+		}
+	}
+	if !common {
+		t.Errorf("BandKeys() have no common key, similarity %.3f", Similarity(a, b))
+	}
+
+	if got := BandKeys(nil); got != nil {
+		t.Errorf("BandKeys(nil) = %v, want nil", got)
+	}
+}
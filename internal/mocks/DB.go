@@ -14,6 +14,34 @@ type DB struct {
 	mock.Mock
 }
 
+// AddFeed provides a mock function with given fields: ctx, feed
+func (_m *DB) AddFeed(ctx context.Context, feed storage.Feed) (string, error) {
+	ret := _m.Called(ctx, feed)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddFeed")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Feed) (string, error)); ok {
+		return rf(ctx, feed)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Feed) string); ok {
+		r0 = rf(ctx, feed)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.Feed) error); ok {
+		r1 = rf(ctx, feed)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddPosts provides a mock function with given fields: ctx, posts
 func (_m *DB) AddPosts(ctx context.Context, posts <-chan storage.Post) (int, error) {
 	ret := _m.Called(ctx, posts)
@@ -42,6 +70,34 @@ func (_m *DB) AddPosts(ctx context.Context, posts <-chan storage.Post) (int, err
 	return r0, r1
 }
 
+// AddSource provides a mock function with given fields: ctx, src
+func (_m *DB) AddSource(ctx context.Context, src storage.Source) (string, error) {
+	ret := _m.Called(ctx, src)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddSource")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Source) (string, error)); ok {
+		return rf(ctx, src)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Source) string); ok {
+		r0 = rf(ctx, src)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.Source) error); ok {
+		r1 = rf(ctx, src)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Close provides a mock function with given fields:
 func (_m *DB) Close() error {
 	ret := _m.Called()
@@ -95,6 +151,150 @@ func (_m *DB) Count(ctx context.Context, q ...*storage.Options) (int64, error) {
 	return r0, r1
 }
 
+// FeedById provides a mock function with given fields: ctx, id
+func (_m *DB) FeedById(ctx context.Context, id string) (storage.Feed, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FeedById")
+	}
+
+	var r0 storage.Feed
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Feed, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Feed); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(storage.Feed)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FeedState provides a mock function with given fields: ctx, url
+func (_m *DB) FeedState(ctx context.Context, url string) (storage.FeedState, error) {
+	ret := _m.Called(ctx, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FeedState")
+	}
+
+	var r0 storage.FeedState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.FeedState, error)); ok {
+		return rf(ctx, url)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.FeedState); ok {
+		r0 = rf(ctx, url)
+	} else {
+		r0 = ret.Get(0).(storage.FeedState)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, url)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FeedStates provides a mock function with given fields: ctx
+func (_m *DB) FeedStates(ctx context.Context) ([]storage.FeedState, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FeedStates")
+	}
+
+	var r0 []storage.FeedState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.FeedState, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.FeedState); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.FeedState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Feeds provides a mock function with given fields: ctx
+func (_m *DB) Feeds(ctx context.Context) ([]storage.Feed, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Feeds")
+	}
+
+	var r0 []storage.Feed
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Feed, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Feed); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Feed)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FeedsSeeded provides a mock function with given fields: ctx
+func (_m *DB) FeedsSeeded(ctx context.Context) (bool, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FeedsSeeded")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (bool, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) bool); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PostById provides a mock function with given fields: ctx, id
 func (_m *DB) PostById(ctx context.Context, id string) (storage.Post, error) {
 	ret := _m.Called(ctx, id)
@@ -123,6 +323,34 @@ func (_m *DB) PostById(ctx context.Context, id string) (storage.Post, error) {
 	return r0, r1
 }
 
+// PostByKey provides a mock function with given fields: ctx, sourceID, key
+func (_m *DB) PostByKey(ctx context.Context, sourceID string, key string) (storage.Post, error) {
+	ret := _m.Called(ctx, sourceID, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PostByKey")
+	}
+
+	var r0 storage.Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.Post, error)); ok {
+		return rf(ctx, sourceID, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.Post); ok {
+		r0 = rf(ctx, sourceID, key)
+	} else {
+		r0 = ret.Get(0).(storage.Post)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, sourceID, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Posts provides a mock function with given fields: ctx, op
 func (_m *DB) Posts(ctx context.Context, op ...*storage.Options) ([]storage.Post, error) {
 	_va := make([]interface{}, len(op))
@@ -160,6 +388,214 @@ func (_m *DB) Posts(ctx context.Context, op ...*storage.Options) ([]storage.Post
 	return r0, r1
 }
 
+// RemoveFeed provides a mock function with given fields: ctx, id
+func (_m *DB) RemoveFeed(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveFeed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Revisions provides a mock function with given fields: ctx, postID
+func (_m *DB) Revisions(ctx context.Context, postID string) ([]storage.Revision, error) {
+	ret := _m.Called(ctx, postID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revisions")
+	}
+
+	var r0 []storage.Revision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.Revision, error)); ok {
+		return rf(ctx, postID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Revision); ok {
+		r0 = rf(ctx, postID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Revision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, postID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetFeedState provides a mock function with given fields: ctx, st
+func (_m *DB) SetFeedState(ctx context.Context, st storage.FeedState) error {
+	ret := _m.Called(ctx, st)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetFeedState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.FeedState) error); ok {
+		r0 = rf(ctx, st)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetFeedsSeeded provides a mock function with given fields: ctx
+func (_m *DB) SetFeedsSeeded(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetFeedsSeeded")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetSubscription provides a mock function with given fields: ctx, sub
+func (_m *DB) SetSubscription(ctx context.Context, sub storage.Subscription) error {
+	ret := _m.Called(ctx, sub)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Subscription) error); ok {
+		r0 = rf(ctx, sub)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Sources provides a mock function with given fields: ctx
+func (_m *DB) Sources(ctx context.Context) ([]storage.Source, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sources")
+	}
+
+	var r0 []storage.Source
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Source, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Source); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Source)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Subscription provides a mock function with given fields: ctx, feedID
+func (_m *DB) Subscription(ctx context.Context, feedID string) (storage.Subscription, error) {
+	ret := _m.Called(ctx, feedID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscription")
+	}
+
+	var r0 storage.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Subscription, error)); ok {
+		return rf(ctx, feedID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Subscription); ok {
+		r0 = rf(ctx, feedID)
+	} else {
+		r0 = ret.Get(0).(storage.Subscription)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, feedID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Tags provides a mock function with given fields: ctx
+func (_m *DB) Tags(ctx context.Context) ([]storage.TagCount, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Tags")
+	}
+
+	var r0 []storage.TagCount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.TagCount, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.TagCount); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.TagCount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateFeed provides a mock function with given fields: ctx, feed
+func (_m *DB) UpdateFeed(ctx context.Context, feed storage.Feed) error {
+	ret := _m.Called(ctx, feed)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateFeed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Feed) error); ok {
+		r0 = rf(ctx, feed)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewDB creates a new instance of DB. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewDB(t interface {
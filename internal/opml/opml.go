@@ -0,0 +1,167 @@
+// Пакет для импорта и экспорта списка RSS лент в формате OPML 2.0.
+package opml
+
+import (
+	"GoNews/internal/storage"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNotOPML - документ не является OPML.
+var ErrNotOPML = errors.New("document is not OPML")
+
+// groupSep - разделитель папок в пути группы ленты.
+const groupSep = "/"
+
+// OPML - корневой элемент документа OPML.
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+// Head - заголовок документа OPML.
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+// Body - тело документа OPML со списком элементов outline.
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline - элемент документа OPML. Элемент с атрибутом xmlUrl
+// описывает ленту, элемент без него - папку с вложенными элементами.
+type Outline struct {
+	Type     string    `xml:"type,attr,omitempty"`
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Parse читает документ OPML и возвращает описанные в нем ленты
+// в порядке следования. Название ленты берется из атрибута title или
+// text, группа - из названий папок, в которые вложена лента. Адреса
+// лент не проверяются.
+func Parse(r io.Reader) ([]storage.Feed, error) {
+	const operation = "opml.Parse"
+
+	var doc OPML
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charset.NewReaderLabel
+	err := dec.Decode(&doc)
+	if err != nil {
+		var se xml.UnmarshalError
+		if errors.As(err, &se) {
+			return nil, fmt.Errorf("%s: %w", operation, ErrNotOPML)
+		}
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	var feeds []storage.Feed
+	walk(doc.Body.Outlines, nil, &feeds)
+	return feeds, nil
+}
+
+// walk рекурсивно обходит элементы outline и добавляет найденные ленты.
+// path - названия папок, в которые вложены элементы.
+func walk(outlines []Outline, path []string, feeds *[]storage.Feed) {
+	for _, o := range outlines {
+		name := strings.TrimSpace(o.Title)
+		if name == "" {
+			name = strings.TrimSpace(o.Text)
+		}
+
+		if o.XMLURL == "" {
+			walk(o.Outlines, append(path, name), feeds)
+			continue
+		}
+
+		*feeds = append(*feeds, storage.Feed{
+			URL:     strings.TrimSpace(o.XMLURL),
+			Name:    name,
+			Group:   strings.Join(path, groupSep),
+			Enabled: true,
+		})
+	}
+}
+
+// Write записывает в w документ OPML 2.0 с переданными лентами. Ленты
+// раскладываются по вложенным папкам согласно группам, порядок лент
+// и папок сохраняется.
+func Write(w io.Writer, title string, feeds []storage.Feed) error {
+	const operation = "opml.Write"
+
+	doc := OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, f := range feeds {
+		var path []string
+		if f.Group != "" {
+			path = strings.Split(f.Group, groupSep)
+		}
+		doc.Body.Outlines = insert(doc.Body.Outlines, path, outlineConv(f))
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	err = enc.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	_, err = io.WriteString(w, "\n")
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	return nil
+}
+
+// insert добавляет элемент ленты в папку по пути path, создавая
+// отсутствующие папки, и возвращает измененный список элементов.
+func insert(outlines []Outline, path []string, feed Outline) []Outline {
+	if len(path) == 0 {
+		return append(outlines, feed)
+	}
+	for i := range outlines {
+		if outlines[i].XMLURL == "" && outlines[i].Text == path[0] {
+			outlines[i].Outlines = insert(outlines[i].Outlines, path[1:], feed)
+			return outlines
+		}
+	}
+	folder := Outline{Text: path[0], Title: path[0]}
+	folder.Outlines = insert(nil, path[1:], feed)
+	return append(outlines, folder)
+}
+
+// outlineConv преобразует ленту из БД в элемент outline. Если название
+// ленты не задано, в обязательный атрибут text записывается адрес.
+func outlineConv(feed storage.Feed) Outline {
+	name := feed.Name
+	if name == "" {
+		name = feed.URL
+	}
+	return Outline{
+		Type:   "rss",
+		Text:   name,
+		Title:  name,
+		XMLURL: feed.URL,
+	}
+}
@@ -0,0 +1,99 @@
+// Пакет для импорта и экспорта списка RSS лент в формате OPML 2.0.
+package opml
+
+import (
+	"GoNews/internal/storage"
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	file, err := os.ReadFile("testOPML.xml")
+	if err != nil {
+		t.Fatalf("Parse() error = cannot read test file")
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    []storage.Feed
+		wantErr error
+	}{
+		{
+			name: "OK",
+			data: string(file),
+			want: []storage.Feed{
+				{URL: "https://habr.com/ru/rss/best/daily/", Name: "Хабр", Enabled: true},
+				{URL: "https://go.dev/blog/feed.atom", Name: "The Go Blog", Group: "Go", Enabled: true},
+				{URL: "https://golangweekly.com/rss", Name: "Golang Weekly", Group: "Go/Сообщество", Enabled: true},
+				{URL: "asdf", Name: "Некорректный адрес", Enabled: true},
+			},
+		},
+		{
+			name:    "Not_OPML",
+			data:    `<rss version="2.0"><channel></channel></rss>`,
+			wantErr: ErrNotOPML,
+		},
+		{
+			name: "Empty_Body",
+			data: `<opml version="1.0"><head/><body/></opml>`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(strings.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWrite позволяет проверить, что экспортированный документ
+// разбирается обратно в те же ленты с теми же группами.
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	feeds := []storage.Feed{
+		{URL: "https://go.dev/blog/feed.atom", Name: "The Go Blog", Group: "Go", Enabled: true},
+		{URL: "https://habr.com/ru/rss/best/daily/", Name: "Хабр & Ко", Enabled: true},
+		{URL: "https://golangweekly.com/rss", Group: "Go/Сообщество", Enabled: true},
+		{URL: "https://blog.golang.org/feed.atom", Name: "Old Go Blog", Group: "Go", Enabled: true},
+	}
+
+	var buf bytes.Buffer
+	err := Write(&buf, "GoNews", feeds)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `<opml version="2.0">`) {
+		t.Errorf("Write() = %s, want OPML 2.0 document", buf.String())
+	}
+
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// Ленты собираются в папки в порядке первого появления, лента без
+	// названия получает название по адресу.
+	want := []storage.Feed{feeds[0], feeds[2], feeds[3], feeds[1]}
+	want[1].Name = want[1].URL
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Write() = %+v, want %+v", got, want)
+	}
+}
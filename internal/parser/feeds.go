@@ -0,0 +1,303 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/config"
+	"GoNews/internal/discover"
+	"GoNews/internal/logger"
+	"GoNews/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// worker - запущенный опрос одной ленты. Контекст ctx отменяется
+// при остановке опроса.
+type worker struct {
+	feed   storage.Feed
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// pushes - обновления ленты, доставленные хабом WebSub
+	// и записываемые в БД вне запроса хаба.
+	pushes sync.WaitGroup
+}
+
+// wait дожидается завершения опроса и записи доставленных обновлений.
+func (w *worker) wait() {
+	<-w.done
+	w.pushes.Wait()
+}
+
+// AddFeed проверяет адрес ленты, записывает ее в БД и, если лента
+// включена, сразу запускает ее опрос. Возвращает ленту с ID из БД.
+func (p *Parser) AddFeed(ctx context.Context, feed storage.Feed) (storage.Feed, error) {
+	const operation = "parser.AddFeed"
+
+	err := validate(feed)
+	if err != nil {
+		return feed, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	feed.ID, err = p.storage.AddFeed(ctx, feed)
+	if err != nil {
+		return feed, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	p.run(feed)
+	slog.Info("feed added", slog.String("id", feed.ID), slog.String("url", feed.URL))
+	return feed, nil
+}
+
+// UpdateFeed записывает новые настройки ленты в БД и перезапускает
+// ее опрос с этими настройками. Выключенная лента останавливается.
+func (p *Parser) UpdateFeed(ctx context.Context, feed storage.Feed) error {
+	const operation = "parser.UpdateFeed"
+
+	err := validate(feed)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	err = p.storage.UpdateFeed(ctx, feed)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	p.stop(feed.ID)
+	p.run(feed)
+	slog.Info("feed updated", slog.String("id", feed.ID), slog.String("url", feed.URL))
+	return nil
+}
+
+// RemoveFeed останавливает опрос ленты и удаляет ее из БД. Если удалить
+// ленту из БД не удалось, ее опрос запускается снова.
+func (p *Parser) RemoveFeed(ctx context.Context, id string) error {
+	const operation = "parser.RemoveFeed"
+
+	w := p.stop(id)
+
+	err := p.storage.RemoveFeed(ctx, id)
+	if err != nil {
+		if w != nil {
+			p.run(w.feed)
+		}
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	slog.Info("feed removed", slog.String("id", id))
+	return nil
+}
+
+// Discover ищет ленты по адресу страницы сайта клиентом парсера.
+// Если адрес сам указывает на ленту, возвращает ее.
+func (p *Parser) Discover(ctx context.Context, url string) ([]discover.Feed, error) {
+	const operation = "parser.Discover"
+
+	err := validate(storage.Feed{URL: url})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, discoverTime)
+	defer cancel()
+	feeds, err := discover.Discover(ctx, p.client, url)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+	return feeds, nil
+}
+
+// ImportResult - результат импорта одной ленты. Для принятой ленты
+// заполнен ID из БД, для отклоненной - причина отказа в Error.
+type ImportResult struct {
+	URL      string `json:"url"`
+	Name     string `json:"name,omitempty"`
+	Group    string `json:"group,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AddFunc - функция добавления одной ленты. Возвращает ленту с ID из БД.
+// Ей соответствует метод Parser.AddFeed.
+type AddFunc func(ctx context.Context, feed storage.Feed) (storage.Feed, error)
+
+// Store возвращает функцию добавления, которая проверяет адрес ленты так
+// же, как при запуске парсера, и записывает ленту в БД без запуска
+// опроса. Ленты начнут опрашиваться при следующем запуске сервиса.
+func Store(st storage.DB) AddFunc {
+	return func(ctx context.Context, feed storage.Feed) (storage.Feed, error) {
+		const operation = "parser.Store"
+
+		err := validate(feed)
+		if err != nil {
+			return feed, fmt.Errorf("%s: %w", operation, err)
+		}
+		feed.ID, err = st.AddFeed(ctx, feed)
+		if err != nil {
+			return feed, fmt.Errorf("%s: %w", operation, err)
+		}
+		return feed, nil
+	}
+}
+
+// Import добавляет ленты по одной переданной функцией и возвращает
+// результаты в порядке переданных лент. Некорректный адрес или повтор
+// отклоняют только свою ленту, прочая ошибка (например, недоступность
+// БД) прерывает импорт: оставшиеся ленты отклоняются без попытки
+// добавления.
+func Import(ctx context.Context, feeds []storage.Feed, add AddFunc) []ImportResult {
+	res := make([]ImportResult, 0, len(feeds))
+	var fatal error
+	for _, feed := range feeds {
+		r := ImportResult{URL: feed.URL, Name: feed.Name, Group: feed.Group}
+		if fatal != nil {
+			r.Error = "failed to add feed"
+			res = append(res, r)
+			continue
+		}
+
+		feed, err := add(ctx, feed)
+		switch {
+		case err == nil:
+			r.ID = feed.ID
+			r.Accepted = true
+		case errors.Is(err, ErrInvalidURL):
+			r.Error = "invalid feed url"
+		case errors.Is(err, storage.ErrDuplicate):
+			r.Error = "feed already exists"
+		default:
+			fatal = err
+			r.Error = "failed to add feed"
+			slog.Error("failed to import feed", slog.String("url", feed.URL), logger.Err(err))
+		}
+		res = append(res, r)
+	}
+
+	var n int
+	for _, r := range res {
+		if r.Accepted {
+			n++
+		}
+	}
+	slog.Info("feeds imported", slog.Int("accepted", n), slog.Int("rejected", len(res)-n))
+	return res
+}
+
+// validate проверяет настройки ленты перед записью в БД и запуском
+// опроса. Возвращает ErrInvalidURL, если адрес ленты некорректен.
+func validate(feed storage.Feed) error {
+	err := valid.Var(feed.URL, "url")
+	if err != nil {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// run запускает опрос ленты в отдельной горутине, если лента включена
+// и ее адрес корректен. Уже запущенный опрос ленты с тем же ID
+// останавливается. Возвращает true, если опрос запущен.
+func (p *Parser) run(feed storage.Feed) bool {
+	if !feed.Enabled {
+		slog.Info("feed disabled", slog.String("url", feed.URL))
+		p.disable(feed.URL)
+		return false
+	}
+	err := validate(feed)
+	if err != nil {
+		slog.Error("invalid url", slog.String("url", feed.URL))
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{
+		feed:   feed,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	p.mu.Lock()
+	if p.workers == nil {
+		p.workers = make(map[string]*worker)
+	}
+	old := p.workers[feed.ID]
+	p.workers[feed.ID] = w
+	p.mu.Unlock()
+
+	if old != nil {
+		old.cancel()
+		old.wait()
+	}
+
+	go func() {
+		defer close(w.done)
+		p.parseRSS(ctx, feed)
+	}()
+	return true
+}
+
+// stop останавливает опрос ленты с переданным ID и дожидается
+// завершения его горутины. Возвращает остановленный опрос или nil,
+// если опрос ленты не был запущен.
+func (p *Parser) stop(id string) *worker {
+	p.mu.Lock()
+	w, ok := p.workers[id]
+	delete(p.workers, id)
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	w.cancel()
+	w.wait()
+	return w
+}
+
+// background запускает f в отдельной горутине с контекстом запущенного
+// опроса ленты с переданным ID. Остановка опроса отменяет контекст
+// и дожидается завершения f. Возвращает false, если опрос ленты
+// не запущен.
+func (p *Parser) background(id string, f func(ctx context.Context)) bool {
+	// Счетчик увеличивается под мьютексом, пока опрос есть в списке,
+	// поэтому остановка опроса, удаляющая его из списка, всегда
+	// дожидается f.
+	p.mu.Lock()
+	w, ok := p.workers[id]
+	if ok {
+		w.pushes.Add(1)
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	go func() {
+		defer w.pushes.Done()
+		f(w.ctx)
+	}()
+	return true
+}
+
+// feedsConv преобразует ленты из файла конфига в ленты для записи в БД.
+func feedsConv(feeds []config.Feed) []storage.Feed {
+	res := make([]storage.Feed, 0, len(feeds))
+	for _, f := range feeds {
+		res = append(res, storage.Feed{
+			URL:      f.URL,
+			Name:     f.Name,
+			Group:    f.Group,
+			Period:   f.Period,
+			Timeout:  f.Timeout,
+			Enabled:  f.Enabled,
+			FullText: f.FullText,
+			Headers:  f.Headers,
+			Auth:     storage.FeedAuth(f.Auth),
+		})
+	}
+	return res
+}
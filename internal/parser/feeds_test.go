@@ -0,0 +1,167 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/logger"
+	"GoNews/internal/mocks"
+	"GoNews/internal/storage"
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// TestParser_Feeds позволяет проверить запуск и остановку опроса лент
+// при их добавлении, изменении и удалении во время работы парсера.
+func TestParser_Feeds(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	// Ресурс всегда недоступен, поэтому после первого запроса опрос
+	// ленты уходит в отсрочку и не обращается к хранилищу постов.
+	rtMock := mocks.NewRoundTripper(t)
+	rtMock.
+		On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Return(nil, errors.New("connection refused")).
+		Maybe()
+
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("FeedState", mock.Anything, mock.AnythingOfType("string")).
+		Return(storage.FeedState{}, storage.ErrNotFound).
+		Maybe()
+	stMock.
+		On("SetFeedState", mock.Anything, mock.AnythingOfType("storage.FeedState")).
+		Return(nil).
+		Maybe()
+	stMock.
+		On("AddFeed", mock.Anything, mock.AnythingOfType("storage.Feed")).
+		Return("1", nil).
+		Once()
+	stMock.
+		On("UpdateFeed", mock.Anything, mock.AnythingOfType("storage.Feed")).
+		Return(nil).
+		Twice()
+	stMock.
+		On("RemoveFeed", mock.Anything, "1").
+		Return(errors.New("DB error")).
+		Once()
+	stMock.
+		On("RemoveFeed", mock.Anything, "1").
+		Return(nil).
+		Once()
+
+	var parser = &Parser{
+		period: time.Minute * 5,
+		client: &http.Client{
+			Transport: rtMock,
+			Timeout:   reqTime,
+		},
+		storage: stMock,
+	}
+	ctx := context.Background()
+
+	// Лента с некорректным адресом не доходит до хранилища.
+	_, err := parser.AddFeed(ctx, storage.Feed{URL: "asdf", Enabled: true})
+	if !errors.Is(err, ErrInvalidURL) {
+		t.Fatalf("Parser.AddFeed() error = %v, want %v", err, ErrInvalidURL)
+	}
+
+	feed, err := parser.AddFeed(ctx, storage.Feed{URL: "https://good-url.com", Enabled: true})
+	if err != nil {
+		t.Fatalf("Parser.AddFeed() error = %v", err)
+	}
+	if feed.ID != "1" || !running(parser, "1") {
+		t.Fatalf("Parser.AddFeed() = %+v, want running feed with ID 1", feed)
+	}
+
+	feed.Enabled = false
+	err = parser.UpdateFeed(ctx, feed)
+	if err != nil {
+		t.Fatalf("Parser.UpdateFeed() error = %v", err)
+	}
+	if running(parser, "1") {
+		t.Errorf("Parser.UpdateFeed() = feed is running, want stopped")
+	}
+
+	feed.Enabled = true
+	err = parser.UpdateFeed(ctx, feed)
+	if err != nil {
+		t.Fatalf("Parser.UpdateFeed() error = %v", err)
+	}
+	if !running(parser, "1") {
+		t.Errorf("Parser.UpdateFeed() = feed is stopped, want running")
+	}
+
+	// При ошибке удаления из БД опрос ленты продолжается.
+	err = parser.RemoveFeed(ctx, "1")
+	if err == nil {
+		t.Fatalf("Parser.RemoveFeed() error = nil, want DB error")
+	}
+	if !running(parser, "1") {
+		t.Errorf("Parser.RemoveFeed() = feed is stopped, want running")
+	}
+
+	err = parser.RemoveFeed(ctx, "1")
+	if err != nil {
+		t.Fatalf("Parser.RemoveFeed() error = %v", err)
+	}
+	if running(parser, "1") {
+		t.Errorf("Parser.RemoveFeed() = feed is running, want stopped")
+	}
+
+	parser.Shutdown()
+}
+
+// TestImport позволяет проверить результаты импорта лент: некорректные
+// адреса и повторы отклоняются, ошибка БД отклоняет оставшиеся ленты.
+func TestImport(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("AddFeed", mock.Anything, storage.Feed{URL: "https://good-url.com/1", Name: "Good", Group: "News", Enabled: true}).
+		Return("1", nil).
+		Once()
+	stMock.
+		On("AddFeed", mock.Anything, storage.Feed{URL: "https://good-url.com/2", Enabled: true}).
+		Return("", storage.ErrDuplicate).
+		Once()
+	stMock.
+		On("AddFeed", mock.Anything, storage.Feed{URL: "https://good-url.com/3", Enabled: true}).
+		Return("", errors.New("DB error")).
+		Once()
+
+	in := []storage.Feed{
+		{URL: "https://good-url.com/1", Name: "Good", Group: "News", Enabled: true},
+		{URL: "asdf", Name: "Bad", Enabled: true},
+		{URL: "https://good-url.com/2", Enabled: true},
+		{URL: "https://good-url.com/3", Enabled: true},
+		{URL: "https://good-url.com/4", Enabled: true},
+	}
+	want := []ImportResult{
+		{URL: "https://good-url.com/1", Name: "Good", Group: "News", ID: "1", Accepted: true},
+		{URL: "asdf", Name: "Bad", Error: "invalid feed url"},
+		{URL: "https://good-url.com/2", Error: "feed already exists"},
+		{URL: "https://good-url.com/3", Error: "failed to add feed"},
+		{URL: "https://good-url.com/4", Error: "failed to add feed"},
+	}
+
+	got := Import(context.Background(), in, Store(stMock))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() = %+v, want %+v", got, want)
+	}
+}
+
+// running сообщает, запущен ли опрос ленты с переданным ID.
+func running(p *Parser, id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.workers[id]
+	return ok
+}
@@ -0,0 +1,202 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/extract"
+	"GoNews/internal/logger"
+	"GoNews/internal/sanitize"
+	"GoNews/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// fullTextInterval - минимальный интервал между запросами статей
+// к одному сайту.
+const fullTextInterval time.Duration = time.Second * 2
+
+// fullTextRetry - время, через которое повторяется загрузка статьи
+// после ошибки. До этого вместо полного текста используется описание
+// поста из ленты.
+const fullTextRetry time.Duration = time.Hour
+
+// article - результат загрузки полного текста поста. teaser - хеш
+// поста из ленты, при его изменении статья загружается заново.
+type article struct {
+	teaser  string
+	content string
+	html    string
+	// full сообщает, что статья загружена, а не заменена описанием
+	// поста из ленты.
+	full bool
+	// retry - время повторной попытки после неудачной загрузки, нулевое
+	// для успешной.
+	retry time.Time
+}
+
+// articles - полные тексты постов лент из последнего опроса. Хранятся,
+// чтобы не загружать статьи заново при каждом опросе ленты.
+type articles struct {
+	mu    sync.Mutex
+	feeds map[string]map[string]article
+}
+
+// hostLimiter ограничивает частоту запросов к одному сайту.
+type hostLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+// newHostLimiter - конструктор ограничителя частоты запросов.
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// wait ожидает, пока можно будет выполнить запрос к сайту host.
+// Возвращает false, если контекст отменен раньше.
+func (l *hostLimiter) wait(ctx context.Context, host string) bool {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(l.interval)
+
+	// Удаляем устаревшие записи, чтобы список сайтов не рос.
+	if len(l.next) > 1000 {
+		for h, t := range l.next {
+			if t.Before(now) {
+				delete(l.next, h)
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	return wait(ctx, time.Until(at))
+}
+
+// fullText заменяет описание постов из канала posts полным текстом
+// статей, загруженным по ссылкам постов, и его очищенным HTML
+// и отправляет посты в возвращаемый канал. Постам без изображения
+// назначается первое изображение статьи. Если статью загрузить
+// не удалось, у поста остается описание из ленты, а сам пост
+// отмечается FullTextFailed, чтобы БД не заменяла им записанный полный
+// текст. Статьи, загруженные при предыдущем опросе ленты или записанные
+// в БД, используются повторно, пока описание поста в ленте
+// не изменится. Посты обрабатываются по одному по мере поступления.
+func (p *Parser) fullText(ctx context.Context, feed storage.Feed, posts <-chan storage.Post) <-chan storage.Post {
+	out := make(chan storage.Post)
+
+	go func() {
+		defer close(out)
+
+		p.articles.mu.Lock()
+		cache := p.articles.feeds[feed.URL]
+		p.articles.mu.Unlock()
+
+		next := make(map[string]article, len(cache))
+		done := true
+		for post := range posts {
+			key := storage.DedupKey(post)
+			art := p.article(ctx, post, cache[key], next, key)
+			post.Content, post.ContentHTML = art.content, art.html
+			post.FullText, post.FullTextFailed = art.full, !art.full
+			post.Teaser = art.teaser
+			if post.Image == "" {
+				post.Image = firstImage(art.html)
+			}
+
+			select {
+			case out <- post:
+				continue
+			case <-ctx.Done():
+			}
+			done = false
+			break
+		}
+		// Остаток канала вычитывается, чтобы завершить горутины,
+		// отправляющие посты.
+		for range posts {
+		}
+
+		// При прерванном опросе статьи из предыдущего опроса сохраняются.
+		p.articles.mu.Lock()
+		if !done {
+			for k, a := range cache {
+				if _, ok := next[k]; !ok {
+					next[k] = a
+				}
+			}
+		}
+		p.articles.feeds[feed.URL] = next
+		p.articles.mu.Unlock()
+	}()
+
+	return out
+}
+
+// article возвращает полный текст статьи поста из кэша или из БД или
+// загружает его с соблюдением ограничения частоты запросов. Результат
+// записывается в next по ключу key. Если статью загрузить не удалось,
+// возвращает описание поста из ленты.
+func (p *Parser) article(ctx context.Context, post storage.Post, cached article, next map[string]article, key string) article {
+	teaser := storage.ContentHash(post)
+	if cached.teaser == teaser && (cached.retry.IsZero() || time.Now().Before(cached.retry)) {
+		next[key] = cached
+		return cached
+	}
+
+	desc := article{teaser: teaser, content: post.Content, html: post.ContentHTML}
+	u, err := url.Parse(post.Link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return desc
+	}
+
+	// Кэш хранится только в памяти, поэтому после перезапуска сервиса
+	// статья берется из записанного поста, если пост в ленте
+	// не изменился.
+	stored, err := p.storage.PostByKey(ctx, post.SourceID, key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		slog.Warn("cannot receive stored post", slog.String("link", post.Link), logger.Err(err))
+	}
+	if err == nil && stored.FullText && stored.Teaser == teaser {
+		full := article{teaser: teaser, content: stored.Content, html: stored.ContentHTML, full: true}
+		next[key] = full
+		return full
+	}
+
+	if !p.hosts.wait(ctx, u.Host) {
+		return desc
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, reqTime)
+	art, err := extract.Fetch(reqCtx, p.client, post.Link)
+	cancel()
+	if err != nil {
+		if ctx.Err() != nil {
+			return desc
+		}
+		slog.Warn("cannot fetch full text, using feed description", slog.String("link", post.Link), logger.Err(err))
+		desc.retry = time.Now().Add(fullTextRetry)
+		next[key] = desc
+		return desc
+	}
+
+	link := func(s string) string { return p.canon.URL(s, "") }
+	full := article{
+		teaser:  teaser,
+		content: art.Text,
+		html:    sanitize.HTML(art.HTML, contentLink(post.Link, link)),
+		full:    true,
+	}
+	next[key] = full
+	return full
+}
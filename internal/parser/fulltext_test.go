@@ -0,0 +1,222 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/logger"
+	"GoNews/internal/mocks"
+	"GoNews/internal/storage"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// testArticle - страница статьи для загрузки полного текста.
+const testArticle = `<html><body>
+	<nav><a href="/">Главная</a> <a href="/blog/">Блог</a></nav>
+	<article><div class="post-content">
+		<p>Конкурентность в Go строится на двух примитивах: горутинах и каналах. Горутина - это легковесный поток выполнения, которым управляет среда выполнения Go.</p>
+		<p>Каналы позволяют горутинам обмениваться данными без явных блокировок. Отправка в небуферизованный канал блокируется, пока другая горутина не прочитает значение.</p>
+	</div></article>
+	<footer class="footer"><p>Все права защищены, использование материалов только со ссылкой на источник.</p></footer>
+</body></html>`
+
+func TestParser_fullText(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	rtMock := mocks.NewRoundTripper(t)
+	rtMock.
+		On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) (*http.Response, error) {
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Body:       io.NopCloser(strings.NewReader(testArticle)),
+				Header:     make(http.Header),
+				Request:    req,
+			}
+			resp.Header.Set("Content-Type", "text/html; charset=utf-8")
+			if req.URL.Path != "/blog/1" {
+				resp.StatusCode = http.StatusNotFound
+				resp.Status = "404 Not Found"
+				resp.Body = http.NoBody
+			}
+			return resp, nil
+		}).
+		// Вторая статья после ошибки до истечения fullTextRetry
+		// не загружается, первая берется из кэша.
+		Twice()
+
+	// Статьи еще не записаны в БД.
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("PostByKey", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Return(storage.Post{}, storage.ErrNotFound).
+		Twice()
+
+	parser := &Parser{
+		client:  &http.Client{Transport: rtMock},
+		hosts:   newHostLimiter(0),
+		storage: stMock,
+	}
+	parser.articles.feeds = make(map[string]map[string]article)
+
+	feed := storage.Feed{URL: "https://good-url.com/feed", FullText: true}
+	items := []storage.Post{
+		{Title: "Горутины", Content: "Читать далее", Link: "https://good-url.com/blog/1"},
+		{Title: "Каналы", Content: "Читать далее", ContentHTML: "<p>Читать далее</p>", Link: "https://good-url.com/blog/2"},
+		{Title: "Без ссылки", Content: "Описание"},
+	}
+
+	for i := 0; i < 2; i++ {
+		posts := make(chan storage.Post, len(items))
+		for _, p := range items {
+			posts <- p
+		}
+		close(posts)
+
+		var got []storage.Post
+		for p := range parser.fullText(context.Background(), feed, posts) {
+			got = append(got, p)
+		}
+
+		if len(got) != len(items) {
+			t.Fatalf("Parser.fullText() = %d posts, want %d", len(got), len(items))
+		}
+		if !strings.HasPrefix(got[0].Content, "Конкурентность в Go") || strings.Contains(got[0].Content, "Главная") {
+			t.Errorf("Parser.fullText() content = %q, want full text", got[0].Content)
+		}
+		if !strings.Contains(got[0].ContentHTML, "<p>Конкурентность в Go") || strings.Contains(got[0].ContentHTML, "Главная") {
+			t.Errorf("Parser.fullText() html = %q, want sanitized article HTML", got[0].ContentHTML)
+		}
+		// Если статью загрузить не удалось, остается описание из ленты.
+		if got[1].Content != "Читать далее" || got[1].ContentHTML != "<p>Читать далее</p>" || got[2].Content != "Описание" {
+			t.Errorf("Parser.fullText() content = %q, %q, want feed description", got[1].Content, got[2].Content)
+		}
+		// Описание из ленты отмечается, чтобы не заменить записанный
+		// полный текст.
+		if !got[0].FullText || got[0].FullTextFailed || got[1].FullText || !got[1].FullTextFailed {
+			t.Errorf("Parser.fullText() full text = %v, %v, want loaded and failed", got[0].FullText, got[1].FullText)
+		}
+	}
+}
+
+// TestParser_fullText_stored проверяет, что статья, записанная в БД
+// до перезапуска сервиса, не загружается заново, пока пост в ленте
+// не изменился.
+func TestParser_fullText_stored(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	item := storage.Post{SourceID: "1", Title: "Горутины", Content: "Читать далее", Link: "https://good-url.com/blog/1"}
+	changed := storage.Post{SourceID: "1", Title: "Горутины", Content: "Читать далее...", Link: "https://good-url.com/blog/1"}
+	stored := storage.Post{
+		Content:     "Конкурентность в Go",
+		ContentHTML: "<p>Конкурентность в Go</p>",
+		FullText:    true,
+		Teaser:      storage.ContentHash(item),
+	}
+
+	// Загружается только статья измененного поста.
+	rtMock := mocks.NewRoundTripper(t)
+	rtMock.
+		On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) (*http.Response, error) {
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Status:     "200 OK",
+				Body:       io.NopCloser(strings.NewReader(testArticle)),
+				Header:     make(http.Header),
+				Request:    req,
+			}
+			resp.Header.Set("Content-Type", "text/html; charset=utf-8")
+			return resp, nil
+		}).
+		Once()
+
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("PostByKey", mock.Anything, "1", storage.DedupKey(item)).
+		Return(stored, nil).
+		Twice()
+
+	for _, tt := range []struct {
+		post storage.Post
+		want string
+	}{
+		{post: item, want: "Конкурентность в Go"},
+		{post: changed, want: "Конкурентность в Go строится"},
+	} {
+		parser := &Parser{
+			client:  &http.Client{Transport: rtMock},
+			hosts:   newHostLimiter(0),
+			storage: stMock,
+		}
+		parser.articles.feeds = make(map[string]map[string]article)
+
+		posts := make(chan storage.Post, 1)
+		posts <- tt.post
+		close(posts)
+
+		got := <-parser.fullText(context.Background(), storage.Feed{URL: "https://good-url.com/feed", FullText: true}, posts)
+		if !strings.HasPrefix(got.Content, tt.want) || !got.FullText {
+			t.Errorf("Parser.fullText() content = %q, want prefix %q", got.Content, tt.want)
+		}
+		if got.Teaser != storage.ContentHash(tt.post) {
+			t.Errorf("Parser.fullText() teaser = %q, want %q", got.Teaser, storage.ContentHash(tt.post))
+		}
+	}
+}
+
+// TestParser_fullText_stream проверяет, что посты обрабатываются
+// по одному, не дожидаясь окончания ленты.
+func TestParser_fullText_stream(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	parser := &Parser{hosts: newHostLimiter(0)}
+	parser.articles.feeds = make(map[string]map[string]article)
+
+	posts := make(chan storage.Post)
+	defer close(posts)
+	out := parser.fullText(context.Background(), storage.Feed{URL: "https://good-url.com/feed", FullText: true}, posts)
+
+	posts <- storage.Post{Title: "Без ссылки", Content: "Описание"}
+	select {
+	case p := <-out:
+		if p.Content != "Описание" {
+			t.Errorf("Parser.fullText() content = %q, want feed description", p.Content)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Parser.fullText() waits for the end of the feed")
+	}
+}
+
+func Test_hostLimiter_wait(t *testing.T) {
+	t.Parallel()
+
+	l := newHostLimiter(50 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if !l.wait(context.Background(), "good-url.com") {
+			t.Fatalf("hostLimiter.wait() = false, want true")
+		}
+	}
+	// Запросы к другому сайту не ждут.
+	l.wait(context.Background(), "other-url.com")
+
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("hostLimiter.wait() elapsed = %v, want about 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if l.wait(ctx, "good-url.com") {
+		t.Errorf("hostLimiter.wait() = true, want false for canceled context")
+	}
+}
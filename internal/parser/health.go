@@ -0,0 +1,80 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/storage"
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// failingAfter - количество ошибок подряд, после которого лента
+// считается недоступной. До этого момента лента считается работающей
+// с перебоями.
+const failingAfter int = 3
+
+// maxBackoff - максимальная отсрочка опроса после ошибок. Если период
+// опроса ленты больше, то отсрочка ограничивается периодом.
+const maxBackoff time.Duration = time.Hour * 24
+
+// markSuccess обновляет состояние ленты после успешного опроса:
+// сбрасывает счетчик ошибок и назначает следующий опрос через период.
+func markSuccess(st *storage.FeedState, now time.Time, period time.Duration) {
+	st.Health = storage.HealthHealthy
+	st.Failures = 0
+	st.LastError = ""
+	st.LastSuccess = now
+	st.NextAttempt = now.Add(period)
+}
+
+// markFailure обновляет состояние ленты после ошибки опроса:
+// увеличивает счетчик ошибок подряд, переводит ленту в состояние
+// degraded или failing и назначает следующий опрос с отсрочкой.
+func markFailure(st *storage.FeedState, err error, now time.Time, period time.Duration) {
+	st.Failures++
+	st.LastError = err.Error()
+	st.LastFailure = now
+
+	st.Health = storage.HealthDegraded
+	if st.Failures >= failingAfter {
+		st.Health = storage.HealthFailing
+	}
+
+	st.NextAttempt = now.Add(backoff(period, st.Failures))
+}
+
+// backoff возвращает отсрочку опроса после failures ошибок подряд.
+// Отсрочка растет экспоненциально от периода опроса до maxBackoff.
+// Чтобы ленты, упавшие одновременно, не опрашивались тоже одновременно,
+// к отсрочке добавляется случайный разброс: итоговое значение лежит
+// в диапазоне [d/2, d].
+func backoff(period time.Duration, failures int) time.Duration {
+	limit := max(maxBackoff, period)
+
+	d := period
+	for i := 0; i < failures && d < limit; i++ {
+		d *= 2
+	}
+	d = min(d, limit)
+
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// wait ожидает истечения переданного времени. Возвращает false, если
+// контекст был отменен раньше.
+func wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,116 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/storage"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Test_markFailure позволяет проверить переходы между состояниями
+// ленты при ошибках подряд и возврат в healthy после успешного опроса.
+func Test_markFailure(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	period := time.Minute * 5
+	errFetch := errors.New("connection refused")
+
+	var st storage.FeedState
+	want := []string{
+		storage.HealthDegraded,
+		storage.HealthDegraded,
+		storage.HealthFailing,
+		storage.HealthFailing,
+	}
+	for i, health := range want {
+		markFailure(&st, errFetch, now, period)
+		if st.Health != health || st.Failures != i+1 {
+			t.Errorf("markFailure() = %v with %d failures, want %v with %d failures", st.Health, st.Failures, health, i+1)
+		}
+		if st.LastError != errFetch.Error() || !st.LastFailure.Equal(now) {
+			t.Errorf("markFailure() state = %+v, want last error %v", st, errFetch)
+		}
+		if !st.NextAttempt.After(now) {
+			t.Errorf("markFailure() next attempt = %v, want after %v", st.NextAttempt, now)
+		}
+	}
+
+	markSuccess(&st, now, period)
+	if st.Health != storage.HealthHealthy || st.Failures != 0 || st.LastError != "" {
+		t.Errorf("markSuccess() state = %+v, want healthy without failures", st)
+	}
+	if !st.NextAttempt.Equal(now.Add(period)) {
+		t.Errorf("markSuccess() next attempt = %v, want %v", st.NextAttempt, now.Add(period))
+	}
+}
+
+// Test_backoff позволяет проверить рост отсрочки и ее ограничение.
+func Test_backoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		period   time.Duration
+		failures int
+		max      time.Duration
+	}{
+		{
+			name:     "First_Failure",
+			period:   time.Minute * 5,
+			failures: 1,
+			max:      time.Minute * 10,
+		},
+		{
+			name:     "Third_Failure",
+			period:   time.Minute * 5,
+			failures: 3,
+			max:      time.Minute * 40,
+		},
+		{
+			name:     "Max_Backoff",
+			period:   time.Minute * 5,
+			failures: 100,
+			max:      maxBackoff,
+		},
+		{
+			name:     "Period_Above_Max_Backoff",
+			period:   time.Hour * 24 * 7,
+			failures: 5,
+			max:      time.Hour * 24 * 7,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Из-за случайного разброса проверяем несколько значений.
+			for i := 0; i < 100; i++ {
+				got := backoff(tt.period, tt.failures)
+				if got < tt.max/2 || got > tt.max {
+					t.Fatalf("backoff() = %v, want in [%v, %v]", got, tt.max/2, tt.max)
+				}
+			}
+		})
+	}
+}
+
+// Test_wait позволяет проверить прерывание ожидания при отмене
+// контекста.
+func Test_wait(t *testing.T) {
+	t.Parallel()
+
+	if !wait(context.Background(), time.Millisecond) {
+		t.Errorf("wait() = false, want true")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if wait(ctx, time.Hour) {
+		t.Errorf("wait() = true, want false")
+	}
+}
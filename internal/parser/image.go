@@ -0,0 +1,89 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/rss"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// imageExts - расширения файлов изображений. По ним определяются
+// вложения-изображения, для которых лента не указала тип.
+var imageExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+	".avif": true,
+}
+
+// leadImage возвращает адрес главного изображения поста: первое
+// вложение-изображение, изображение из элементов Media RSS или первое
+// изображение в описании поста. Адрес может быть относительным.
+func leadImage(i rss.Item) string {
+	for _, e := range i.Enclosures {
+		if isImage(e) {
+			return e.URL
+		}
+	}
+	if i.Image != "" {
+		return i.Image
+	}
+	return firstImage(i.Description)
+}
+
+// isImage сообщает, что вложение является изображением, по его типу
+// или, если тип не указан, по расширению файла.
+func isImage(e rss.Enclosure) bool {
+	if e.Type != "" {
+		return strings.HasPrefix(strings.ToLower(e.Type), "image/")
+	}
+	u := e.URL
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		u = u[:i]
+	}
+	return imageExts[strings.ToLower(path.Ext(u))]
+}
+
+// firstImage возвращает адрес первого изображения в HTML фрагменте.
+// Встроенные изображения data: и пиксели отслеживания размером
+// в 1 точку пропускаются.
+func firstImage(raw string) string {
+	if !strings.Contains(raw, "<") {
+		return ""
+	}
+
+	z := html.NewTokenizer(strings.NewReader(raw))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return ""
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		tok := z.Token()
+		if tok.DataAtom != atom.Img {
+			continue
+		}
+
+		var src string
+		pixel := false
+		for _, a := range tok.Attr {
+			switch a.Key {
+			case "src":
+				src = strings.TrimSpace(a.Val)
+			case "width", "height":
+				v := strings.TrimSpace(a.Val)
+				pixel = pixel || v == "0" || v == "1"
+			}
+		}
+		if src != "" && !pixel && !strings.HasPrefix(strings.ToLower(src), "data:") {
+			return src
+		}
+	}
+}
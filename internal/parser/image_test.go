@@ -0,0 +1,67 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/rss"
+	"testing"
+)
+
+func Test_leadImage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		item rss.Item
+		want string
+	}{
+		{
+			name: "Enclosure",
+			item: rss.Item{
+				Description: `<img src="https://a.com/desc.jpg">`,
+				Image:       "https://a.com/media.jpg",
+				Enclosures: []rss.Enclosure{
+					{URL: "https://a.com/podcast.mp3", Type: "audio/mpeg"},
+					{URL: "https://a.com/cover.jpg", Type: "image/jpeg"},
+				},
+			},
+			want: "https://a.com/cover.jpg",
+		},
+		{
+			name: "Enclosure_Without_Type",
+			item: rss.Item{Enclosures: []rss.Enclosure{{URL: "https://a.com/cover.PNG?w=600"}}},
+			want: "https://a.com/cover.PNG?w=600",
+		},
+		{
+			name: "Media",
+			item: rss.Item{
+				Description: `<img src="https://a.com/desc.jpg">`,
+				Image:       "https://a.com/media.jpg",
+				Enclosures:  []rss.Enclosure{{URL: "https://a.com/podcast.mp3"}},
+			},
+			want: "https://a.com/media.jpg",
+		},
+		{
+			name: "Description",
+			item: rss.Item{
+				Description: `<p>Текст<img src="data:image/gif;base64,R0lGOD" alt=""><img src="https://a.com/pixel.gif" width="1" height="1"></p><img src=" /images/lead.jpg " />`,
+			},
+			want: "/images/lead.jpg",
+		},
+		{
+			name: "No_Image",
+			item: rss.Item{Description: "Текст без изображений"},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := leadImage(tt.item); got != tt.want {
+				t.Errorf("leadImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,21 +2,28 @@
 package parser
 
 import (
+	"GoNews/internal/canonical"
 	"GoNews/internal/config"
+	"GoNews/internal/lang"
 	"GoNews/internal/logger"
+	"GoNews/internal/minhash"
+	"GoNews/internal/pubdate"
 	"GoNews/internal/rss"
+	"GoNews/internal/sanitize"
 	"GoNews/internal/storage"
+	"GoNews/internal/websub"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
 	"regexp"
 	"sync"
 	"time"
-	"unicode"
-	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
 	strip "github.com/grokify/html-strip-tags-go"
@@ -25,155 +32,540 @@ import (
 // reqTime - таймаут для запроса RSS ленты.
 const reqTime time.Duration = time.Second * 10
 
+// discoverTime - таймаут поиска лент по адресу страницы, включая
+// проверку распространенных путей на сайте.
+const discoverTime time.Duration = time.Second * 30
+
+// futureSkew - допустимое опережение даты публикации относительно
+// текущего времени.
+const futureSkew time.Duration = time.Hour * 24
+
+// Ограничения на размер ленты по умолчанию, если они не указаны
+// в файле конфига.
+const (
+	defaultMaxBodySize int64 = 10 << 20
+	defaultMaxItems    int   = 500
+)
+
+// drainSize - максимальное количество байт, вычитываемых из тела ответа
+// перед его закрытием. Остаток слишком большого тела не вычитывается,
+// соединение в этом случае просто закрывается.
+const drainSize int64 = 64 << 10
+
 var (
-	ErrNoLinks = errors.New("RSS section of the config file has no correct URLs")
+	ErrInvalidURL  = errors.New("invalid feed url")
+	ErrBadStatus   = errors.New("unexpected response status")
+	ErrNotModified = errors.New("the feed is not modified")
+	ErrNoWebSub    = errors.New("websub subscriptions are disabled")
 )
 
-// Parser - структура парсера RSS лент.
+// valid проверяет адреса лент на корректность. Валидатор кэширует
+// описания структур и безопасен для конкурентного использования,
+// поэтому создается один раз.
+var valid = validator.New()
+
+// Parser - структура парсера RSS лент. Список лент хранится в БД,
+// а ленты из файла конфига используются только для первоначального
+// заполнения списка при первом запуске.
 type Parser struct {
-	links   []string
+	seed    []storage.Feed
 	period  time.Duration
 	client  *http.Client
+	limits  rss.Limits
 	storage storage.DB
-	done    chan bool
+
+	// canon приводит ссылки постов к каноническому виду перед записью
+	// в БД.
+	canon *canonical.Canonicalizer
+
+	// articles и hosts используются для загрузки полного текста статей
+	// лент, у которых он включен.
+	articles articles
+	hosts    *hostLimiter
+
+	// tags - правила автоматической разметки постов тегами.
+	tags []tagRule
+
+	// websub - подписчик на обновления лент через хабы WebSub, nil,
+	// если в конфиге не задан внешний адрес сервера.
+	websub *websub.Subscriber
+
+	// mu защищает workers - запущенные опросы лент по их ID.
+	mu      sync.Mutex
+	workers map[string]*worker
 }
 
 // New - конструктор парсера RSS.
 func New(cfg *config.Config, st storage.DB) *Parser {
+	limits := rss.Limits{
+		MaxBodySize: cfg.MaxBodySize,
+		MaxItems:    cfg.MaxItems,
+	}
+	if limits.MaxBodySize <= 0 {
+		limits.MaxBodySize = defaultMaxBodySize
+	}
+	if limits.MaxItems <= 0 {
+		limits.MaxItems = defaultMaxItems
+	}
+
+	// Таймаут запроса задается для каждой ленты отдельно через контекст,
+	// поэтому у общего клиента таймаута нет.
 	parser := &Parser{
-		links:  cfg.RSSFeeds,
-		period: cfg.RequestPeriod,
-		client: &http.Client{
-			Timeout: reqTime,
-		},
+		seed:    feedsConv(cfg.RSSFeeds),
+		period:  cfg.RequestPeriod,
+		client:  &http.Client{},
+		limits:  limits,
 		storage: st,
-		done:    make(chan bool, len(cfg.RSSFeeds)),
+		canon:   canonical.New(cfg.TrackingParams),
+		hosts:   newHostLimiter(fullTextInterval),
+		workers: make(map[string]*worker),
+	}
+	parser.articles.feeds = make(map[string]map[string]article)
+	tags, err := tagRules(cfg.Tags)
+	if err != nil {
+		slog.Error("cannot prepare tag rules, posts are not tagged", logger.Err(err))
+	}
+	parser.tags = tags
+	if cfg.WebSubCallback != "" {
+		parser.websub = websub.New(parser.client, cfg.WebSubCallback, st)
 	}
 	return parser
 }
 
-// Start проверяет каждый url из списка ссылок p.links на валидность,
-// затем запускает парсинг в отдельной горутине с шагом, указанным
-// в файле конфига.
+// Start загружает список лент из БД, а при первом запуске дополняет
+// его лентами из файла конфига. Затем запускает опрос каждой включенной
+// ленты с корректным адресом в отдельной горутине с шагом, указанным
+// в настройках ленты или в общем периоде опроса.
 func (p *Parser) Start() error {
-	if len(p.links) == 0 {
-		return ErrNoLinks
+	const operation = "parser.Start"
+	ctx := context.Background()
+
+	feeds, err := p.storage.Feeds(ctx)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Ленты из файла конфига записываются только один раз, поэтому
+	// удаленные через API ленты не появляются снова после перезапуска.
+	// Ленты, уже записанные в БД, например импортом OPML до первого
+	// запуска, пропускаются как повторы.
+	seeded, err := p.storage.FeedsSeeded(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	if !seeded {
+		added, err := p.seedFeeds(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", operation, err)
+		}
+		feeds = append(feeds, added...)
+		err = p.storage.SetFeedsSeeded(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", operation, err)
+		}
 	}
 
 	// Счетчик запущенных парсеров, нужен для вывода в Debug сообщении.
 	var i int
-	// Валидатор нужен для проверки url на корректность.
-	valid := validator.New()
-	for _, url := range p.links {
-		err := valid.Var(url, "url")
-		if err != nil {
-			slog.Error("invalid url", slog.String("url", url))
-			continue
+	for _, feed := range feeds {
+		if p.run(feed) {
+			i++
 		}
-		go p.parseRSS(url)
-		i++
 	}
 
+	// Ленты добавляются и включаются во время работы через API
+	// администратора, поэтому сервис запускается и без них.
 	if i == 0 {
-		return ErrNoLinks
+		slog.Warn("no enabled feeds to parse")
+		return nil
 	}
 
 	slog.Debug(fmt.Sprintf("parser started on %d urls", i))
 	return nil
 }
 
-// Shutdown посылает сигналы для остановки парсинга url.
+// seedFeeds записывает в БД ленты из файла конфига и возвращает
+// успешно записанные. Ленты с некорректным адресом и повторы
+// пропускаются.
+func (p *Parser) seedFeeds(ctx context.Context) ([]storage.Feed, error) {
+	var feeds []storage.Feed
+	for _, feed := range p.seed {
+		err := validate(feed)
+		if err != nil {
+			slog.Error("invalid url", slog.String("url", feed.URL))
+			continue
+		}
+
+		feed.ID, err = p.storage.AddFeed(ctx, feed)
+		if errors.Is(err, storage.ErrDuplicate) {
+			slog.Info("feed from config already stored", slog.String("url", feed.URL))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, feed)
+	}
+
+	slog.Info("feeds seeded from config", slog.Int("feeds", len(feeds)))
+	return feeds, nil
+}
+
+// Shutdown останавливает опрос всех лент и дожидается завершения
+// горутин парсинга и записи доставленных хабом обновлений.
 func (p *Parser) Shutdown() {
-	for i := 0; i < len(p.links); i++ {
-		p.done <- true
+	p.mu.Lock()
+	workers := p.workers
+	p.workers = make(map[string]*worker)
+	p.mu.Unlock()
+
+	for _, w := range workers {
+		w.cancel()
+	}
+	for _, w := range workers {
+		w.wait()
 	}
-	close(p.done)
 }
 
-// parseRSS запускает парсинг RSS ленты с переданного url в бесконечном
-// цикле и с периодом, указанным в парсере. Каждую итерацию цикла
-// запрашивается и десериализуется RSS лента. Затем все новые посты
-// записываются в БД.
-func (p *Parser) parseRSS(url string) {
-	ctx, cancel := context.WithCancel(context.Background())
-	go func() {
-		<-p.done
-		cancel()
-		slog.Debug("parsing stopped", slog.String("url", url))
-	}()
+// parseRSS запускает парсинг переданной RSS ленты в цикле до отмены
+// контекста с периодом из настроек ленты, а если он не указан -
+// с периодом, указанным в парсере. Каждую итерацию цикла запрашивается
+// RSS лента, посты из которой по мере декодирования записываются в БД.
+func (p *Parser) parseRSS(ctx context.Context, feed storage.Feed) {
+	url := feed.URL
+	period := feed.Period
+	if period <= 0 {
+		period = p.period
+	}
+	timeout := feed.Timeout
+	if timeout <= 0 {
+		timeout = reqTime
+	}
+
+	defer slog.Debug("parsing stopped", slog.String("url", url))
 
-	// Создаем новый запрос с контекстом для переданного url. Контекст
-	// используется для отмены запроса в случае остановки парсера.
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	// Создаем новый запрос для переданной ленты. Контекст с таймаутом
+	// из настроек ленты подставляется в запрос на каждой итерации.
+	req, err := newRequest(feed)
 	if err != nil {
 		slog.Error("cannot create new request", slog.String("url", url), logger.Err(err))
 		return
 	}
 
+	// Состояние опроса с заголовками для условных запросов хранится
+	// в БД, чтобы пережить перезапуск сервиса.
+	state, err := p.storage.FeedState(ctx, url)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		slog.Error("cannot receive feed state", slog.String("url", url), logger.Err(err))
+	}
+	state.URL = url
+
+	// Отсрочка после ошибок сохраняется в БД, поэтому после перезапуска
+	// сервиса недоступная лента не опрашивается раньше времени.
+	if !wait(ctx, time.Until(state.NextAttempt)) {
+		return
+	}
+
 	for {
 		slog.Debug("requesting data", slog.String("url", url))
 
-		resp, err := p.client.Do(req)
-		if err != nil {
-			slog.Error("cannot receive a response", slog.String("url", url), logger.Err(err))
-			time.Sleep(p.period)
-			continue
+		fetchCtx, fetchCancel := context.WithTimeout(ctx, timeout)
+		num, err := p.fetch(ctx, req.WithContext(fetchCtx), feed, &state)
+		fetchCancel()
+		// Ошибка из-за остановки парсера не говорит о работоспособности
+		// ленты, поэтому состояние в этом случае не обновляется.
+		if ctx.Err() != nil {
+			return
 		}
 
-		feed, err := rss.Parse(resp.Body)
+		// После ошибок опрос откладывается с растущей отсрочкой, а лента
+		// переходит в degraded и затем в failing. Успешный опрос
+		// возвращает ее в healthy.
+		now := time.Now()
+		switch {
+		case errors.Is(err, ErrNotModified):
+			state.NotModified++
+			slog.Info("Feed not modified", slog.String("url", url), slog.Int("not_modified", state.NotModified))
+			markSuccess(&state, now, period)
+		case errors.Is(err, rss.ErrLimitExceeded):
+			// Посты, полученные до превышения ограничения, уже записаны.
+			slog.Warn("feed limit exceeded", slog.Int("posts", num), slog.String("url", url), logger.Err(err))
+			markSuccess(&state, now, period)
+		case err != nil:
+			markFailure(&state, err, now, period)
+			slog.Error("cannot fetch RSS feed",
+				slog.String("url", url),
+				slog.String("health", state.Health),
+				slog.Int("failures", state.Failures),
+				slog.Time("next_attempt", state.NextAttempt),
+				logger.Err(err),
+			)
+		case num == 0:
+			slog.Info("No posts was added", slog.String("url", url))
+			markSuccess(&state, now, period)
+		default:
+			slog.Info("Posts from url added successfully", slog.Int("posts", num), slog.String("url", url))
+			markSuccess(&state, now, period)
+		}
 
-		// Для корректного переиспользования соединения и освобождения
-		// памяти следует вычитать все тело ответа до EOF и закрыть его,
-		// как указано в описании к методу Do клиента.
-		io.Copy(io.Discard, resp.Body)
-		resp.Body.Close()
+		// Если лента объявляет хаб WebSub, на нее оформляется подписка.
+		// Пока хаб доставляет обновления, лента опрашивается реже.
+		if p.websub != nil && state.Failures == 0 && state.Hub != "" {
+			p.push(ctx, feed, &state, num, period)
+		}
+
+		err = p.storage.SetFeedState(ctx, state)
 		if err != nil {
-			slog.Error("cannot parse RSS feed", slog.String("url", url), logger.Err(err))
-			time.Sleep(p.period)
-			continue
+			slog.Error("cannot save feed state", slog.String("url", url), logger.Err(err))
 		}
 
-		slog.Debug("data parsed successfully", slog.Int("posts", len(feed.Channel.Items)), slog.String("url", url))
+		if !wait(ctx, time.Until(state.NextAttempt)) {
+			return
+		}
+	}
+}
 
-		posts := postConv(feed)
+// disable отмечает в БД выключенную в настройках ленту, чтобы ее
+// состояние было видно среди состояний остальных лент.
+func (p *Parser) disable(url string) {
+	ctx := context.Background()
 
-		slog.Debug("sending data to DB", slog.String("url", url))
+	state, err := p.storage.FeedState(ctx, url)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		slog.Error("cannot receive feed state", slog.String("url", url), logger.Err(err))
+		return
+	}
+	state.URL = url
+	state.Health = storage.HealthDisabled
+	state.NextAttempt = time.Time{}
 
-		num, err := p.storage.AddPosts(ctx, posts)
+	err = p.storage.SetFeedState(ctx, state)
+	if err != nil {
+		slog.Error("cannot save feed state", slog.String("url", url), logger.Err(err))
+	}
+}
+
+// newRequest создает запрос ленты с дополнительными заголовками
+// и учетными данными из ее настроек. Ссылки на переменные окружения
+// в учетных данных раскрываются здесь, чтобы секреты не попадали в БД.
+func newRequest(feed storage.Feed) (*http.Request, error) {
+	req, err := http.NewRequest("GET", feed.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range feed.Headers {
+		req.Header.Set(k, v)
+	}
+
+	switch {
+	case feed.Auth.Token != "":
+		req.Header.Set("Authorization", "Bearer "+os.ExpandEnv(feed.Auth.Token))
+	case feed.Auth.Username != "":
+		req.SetBasicAuth(os.ExpandEnv(feed.Auth.Username), os.ExpandEnv(feed.Auth.Password))
+	}
+
+	return req, nil
+}
+
+// fetch выполняет условный запрос RSS ленты и записывает ее посты
+// в БД, обновляя состояние ленты state. Возвращает количество
+// добавленных постов. На ответ 304 Not Modified возвращает
+// ErrNotModified, а при превышении ограничений p.limits - ошибку,
+// оборачивающую rss.ErrLimitExceeded.
+func (p *Parser) fetch(ctx context.Context, req *http.Request, feed storage.Feed, state *storage.FeedState) (int, error) {
+	const operation = "parser.fetch"
+
+	// Значения заголовков условного запроса берутся из предыдущего
+	// ответа. Таймаут запроса задается контекстом req.
+	setHeader(req.Header, "If-None-Match", state.ETag)
+	setHeader(req.Header, "If-Modified-Since", state.LastModified)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", operation, err)
+	}
+	// Для корректного переиспользования соединения следует вычитать
+	// тело ответа до EOF и закрыть его, как указано в описании к методу
+	// Do клиента. Вычитываем не больше drainSize, чтобы не читать
+	// слишком большое тело целиком.
+	defer func() {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, drainSize))
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return 0, fmt.Errorf("%s: %w", operation, ErrNotModified)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, fmt.Errorf("%s: %w: %s", operation, ErrBadStatus, resp.Status)
+	}
+
+	// Загрузка полного текста статей может продолжаться дольше таймаута
+	// запроса ленты, поэтому тело ответа таких лент читается заранее.
+	// Размер тела ограничен так же, как при декодировании.
+	var body io.Reader = resp.Body
+	if feed.FullText {
+		var r io.Reader = resp.Body
+		if p.limits.MaxBodySize > 0 {
+			r = io.LimitReader(resp.Body, p.limits.MaxBodySize+1)
+		}
+		buf, err := io.ReadAll(r)
 		if err != nil {
-			slog.Error("error on adding posts", slog.String("url", url), logger.Err(err))
-			time.Sleep(p.period)
-			continue
+			return 0, fmt.Errorf("%s: %w", operation, err)
 		}
+		body = bytes.NewReader(buf)
+	}
 
-		switch num {
-		case 0:
-			slog.Info("No posts was added", slog.String("url", url))
-		default:
-			slog.Info("Posts from url added successfully", slog.Int("posts", num), slog.String("url", url))
-		}
+	// Посты декодируются по одному и сразу передаются в хранилище,
+	// поэтому посты ленты целиком в памяти не хранятся. Контекст ctx
+	// ограничивает запись в БД и загрузку полного текста статей.
+	ch, num, err := p.store(ctx, feed, body, resp.Header.Get("Content-Type"))
+	// Посты, полученные до превышения ограничений, уже записаны,
+	// поэтому состояние ленты сохраняется и в этом случае.
+	if err != nil && !errors.Is(err, rss.ErrLimitExceeded) {
+		return num, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Состояние в БД сохраняет вызывающая сторона.
+	state.ETag = resp.Header.Get("ETag")
+	state.LastModified = resp.Header.Get("Last-Modified")
+	state.Hub, state.Topic = hubLinks(resp.Header, ch, feed.URL)
 
-		time.Sleep(p.period)
+	if err != nil {
+		return num, fmt.Errorf("%s: %w", operation, err)
 	}
+	return num, nil
 }
 
-// postConv создает и возвращает канал с емкостью, равной количеству
-// постов из переданной RSS ленты. Асинхронно подготавливает каждый
-// пост и отправляет в канал.
-func postConv(feed rss.Feed) <-chan storage.Post {
-	ln := len(feed.Channel.Items)
-	if ln == 0 {
-		return nil
+// store декодирует ленту из body и записывает ее посты в БД. Возвращает
+// метаданные канала и количество добавленных постов. Используется как
+// для опрошенных лент, так и для обновлений, доставленных хабом WebSub.
+func (p *Parser) store(ctx context.Context, feed storage.Feed, body io.Reader, contentType string) (rss.Channel, int, error) {
+	url := feed.URL
+
+	dec, err := rss.NewDecoder(body, contentType, p.limits)
+	if err != nil {
+		return rss.Channel{}, 0, err
+	}
+
+	slog.Debug("feed metadata decoded", slog.String("format", dec.Format()), slog.String("url", url))
+
+	// Относительные ссылки постов разрешаются относительно ссылки
+	// на сайт из канала, а если ее нет - относительно адреса ленты.
+	ch := dec.Channel()
+	ch.Link = p.canon.URL(ch.Link, url)
+	base := ch.Link
+	if base == "" {
+		base = url
+	}
+
+	srcID, err := p.storage.AddSource(ctx, sourceConv(feed, ch))
+	if err != nil {
+		return dec.Channel(), 0, err
+	}
+
+	// Контекст декодирования отменяется, если хранилище перестало читать
+	// посты раньше окончания ленты, например, из-за ошибки.
+	decCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items, errc := decode(decCtx, dec)
+	posts := postConv(items, srcID, ch.Language, func(link string) string {
+		return p.canon.URL(link, base)
+	})
+	if feed.FullText {
+		posts = p.fullText(decCtx, feed, posts)
+	}
+	if len(p.tags) > 0 {
+		posts = p.tag(feed, posts)
+	}
+
+	slog.Debug("sending data to DB", slog.String("url", url))
+
+	num, err := p.storage.AddPosts(ctx, posts)
+	// Останавливаем декодирование и вычитываем оставшиеся посты, чтобы
+	// завершить горутины, если хранилище прочитало канал не полностью.
+	cancel()
+	for range posts {
+	}
+	if err != nil {
+		return dec.Channel(), num, err
 	}
-	posts := make(chan storage.Post, ln)
-	var wg sync.WaitGroup
-	wg.Add(ln)
+
+	return dec.Channel(), num, <-errc
+}
+
+// setHeader устанавливает заголовок запроса или удаляет его, если
+// значение пустое.
+func setHeader(h http.Header, key, value string) {
+	if value == "" {
+		h.Del(key)
+		return
+	}
+	h.Set(key, value)
+}
+
+// decode читает посты из декодера в отдельной горутине и отправляет
+// их в возвращаемый канал. После окончания ленты или ошибки канал
+// постов закрывается, а в канал ошибок отправляется ошибка
+// декодирования или nil.
+func decode(ctx context.Context, dec *rss.Decoder) (<-chan rss.Item, <-chan error) {
+	items := make(chan rss.Item)
+	errc := make(chan error, 1)
 
 	go func() {
-		wg.Wait()
-		close(posts)
+		defer close(items)
+		for {
+			item, err := dec.Next()
+			if err == io.EOF {
+				errc <- nil
+				return
+			}
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
 	}()
 
+	return items, errc
+}
+
+// sourceConv формирует источник из метаданных канала переданной RSS
+// ленты. Название ленты из настроек имеет приоритет над названием
+// канала.
+func sourceConv(feed storage.Feed, ch rss.Channel) storage.Source {
+	title := ch.Title
+	if feed.Name != "" {
+		title = feed.Name
+	}
+	return storage.Source{
+		URL:         feed.URL,
+		Title:       title,
+		Link:        ch.Link,
+		Description: ch.Description,
+		Language:    ch.Language,
+		Image:       ch.Image,
+	}
+}
+
+// postConv создает и возвращает канал подготовленных постов. Посты
+// из канала items преобразуются по мере поступления, привязываются
+// к источнику srcID и отправляются в возвращаемый канал, который
+// закрывается после закрытия items. Ссылки на пост и вложения
+// приводятся к каноническому виду функцией link, язык канала language
+// используется как язык постов по умолчанию.
+func postConv(items <-chan rss.Item, srcID, language string, link func(string) string) <-chan storage.Post {
+	posts := make(chan storage.Post)
+
 	// Создаем регулярное выражение для вырезания пустых строк из поля
 	// description. Функция StripTags из пакета strip вырезает HTML тэги,
 	// но оставляет много пустых строк, если такие были.
@@ -182,42 +574,80 @@ func postConv(feed rss.Feed) <-chan storage.Post {
 		slog.Error("cannot compile regexp", logger.Err(err))
 	}
 
-	for _, v := range feed.Channel.Items {
-		go func(i rss.Item) {
-			defer wg.Done()
-
+	go func() {
+		defer close(posts)
+		for i := range items {
 			var p storage.Post
+			p.SourceID = srcID
 			p.Title = i.Title
 			desc := strip.StripTags(i.Description)
 			p.Content = regex.ReplaceAllString(desc, "\n")
-			p.Link = i.Link
-			p.PubTime = timeConv(i.PubDate)
+			p.Link = link(i.Link)
+			// Описание сохраняется текстом и очищенным HTML. Ссылки
+			// описания и изображения разрешаются относительно ссылки
+			// на пост.
+			resolve := contentLink(p.Link, link)
+			p.ContentHTML = sanitize.HTML(i.Description, resolve)
+			if img := leadImage(i); img != "" {
+				p.Image = webURL(resolve(img))
+			}
+			p.Lang = lang.Detect(p.Title+"\n"+p.Content, language)
+			// Сигнатура вычисляется по тексту из ленты, так как полный
+			// текст статьи загружается не для всех лент.
+			p.MinHash = minhash.Signature(p.Title, p.Content)
+			p.PubTime, p.PubTimeGuessed = timeConv(i.PubDate)
+			p.GUID = i.GUID
+			p.Author = i.Author
+			p.Categories = i.Categories
+			for _, e := range i.Enclosures {
+				e.URL = link(e.URL)
+				p.Enclosures = append(p.Enclosures, storage.Enclosure(e))
+			}
 			posts <- p
-		}(v)
-	}
+		}
+	}()
 
 	return posts
 }
 
-// timeConv конвертирует переданную дату в time.Time в зависимости от формата.
-// Если формат переданной даты не соответствует проверяемым, то возвращает
-// текущее время и дату.
-func timeConv(str string) time.Time {
-	r, _ := utf8.DecodeLastRuneInString(str)
-	if r == utf8.RuneError {
-		return time.Now()
+// contentLink возвращает функцию преобразования ссылок из содержания
+// поста: ссылка разрешается относительно ссылки на пост base
+// и приводится к каноническому виду функцией link.
+func contentLink(base string, link func(string) string) func(string) string {
+	b, err := url.Parse(base)
+	if err != nil || base == "" {
+		return link
 	}
+	return func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return link(s)
+		}
+		return link(b.ResolveReference(u).String())
+	}
+}
 
-	var t time.Time
-	var err error
-	switch {
-	case unicode.IsDigit(r):
-		t, err = time.Parse(time.RFC1123Z, str)
-	default:
-		t, err = time.Parse(time.RFC1123, str)
+// webURL возвращает ссылку, если ее схема http или https, иначе
+// пустую строку.
+func webURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
 	}
-	if err != nil {
-		return time.Now()
+	return s
+}
+
+// timeConv конвертирует переданную дату публикации в time.Time в UTC.
+// Второе возвращаемое значение сообщает, что дату не удалось разобрать
+// и вместо нее подставлено текущее время. Даты, отстоящие в будущее
+// больше чем на futureSkew, также считаются некорректными, чтобы такие
+// посты не оказывались первыми в выдаче.
+func timeConv(str string) (time.Time, bool) {
+	now := time.Now().UTC()
+
+	t, err := pubdate.Parse(str)
+	if err != nil || t.After(now.Add(futureSkew)) {
+		return now, true
 	}
-	return t
+	return t, false
 }
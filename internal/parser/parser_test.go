@@ -2,17 +2,21 @@
 package parser
 
 import (
+	"GoNews/internal/canonical"
 	"GoNews/internal/logger"
+	"GoNews/internal/minhash"
 	"GoNews/internal/mocks"
 	"GoNews/internal/rss"
 	"GoNews/internal/storage"
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,33 +33,70 @@ func TestParser_Start(t *testing.T) {
 	}
 
 	tests := []struct {
-		name      string
-		urls      []string
-		wantStart int
+		name         string
+		seed         []storage.Feed
+		stored       []storage.Feed
+		seeded       bool
+		wantSeeded   int
+		wantStart    int
+		wantDisabled int
 	}{
 		// Проверяем корректные и некорректные ссылки, а также их
-		// отсутствие. Если wantStart > 0, то мы не хотим получить
-		// ошибку из тестируемой функции.
+		// отсутствие. Отсутствие лент для опроса не считается ошибкой.
+		// Если список еще не заполнялся, то он дополняется из seed,
+		// ленты из stored пропускаются как повторы.
 		{
-			name:      "URL_OK",
-			urls:      []string{"https://good-url.com", "https://good-url.com", "https://good-url.com"},
-			wantStart: 3,
+			name:       "URL_OK",
+			seed:       feeds("https://good-url.com/1", "https://good-url.com/2", "https://good-url.com/3"),
+			wantSeeded: 3,
+			wantStart:  3,
 		},
 		{
 			name:      "No_URL",
-			urls:      []string{},
+			seed:      feeds(),
 			wantStart: 0,
 		},
 		{
 			name:      "Incorrect_URL",
-			urls:      []string{"asdf"},
+			seed:      feeds("asdf"),
 			wantStart: 0,
 		},
 		{
-			name:      "Partially_Correct",
-			urls:      []string{"asdf", "https://good-url.com", "zxcv"},
+			name:       "Partially_Correct",
+			seed:       feeds("asdf", "https://good-url.com", "zxcv"),
+			wantSeeded: 1,
+			wantStart:  1,
+		},
+		{
+			name: "Disabled",
+			seed: []storage.Feed{
+				{URL: "https://good-url.com/1", Enabled: true},
+				{URL: "https://good-url.com/2", Enabled: false},
+			},
+			wantSeeded:   2,
+			wantStart:    1,
+			wantDisabled: 1,
+		},
+		{
+			name:      "Stored_Feeds",
+			seed:      feeds("https://good-url.com/1", "https://good-url.com/2"),
+			stored:    []storage.Feed{{ID: "s1", URL: "https://good-url.com/3", Enabled: true}},
+			seeded:    true,
 			wantStart: 1,
 		},
+		{
+			name:       "Imported_Before_Start",
+			seed:       feeds("https://good-url.com/1", "https://good-url.com/2"),
+			stored:     []storage.Feed{{ID: "s1", URL: "https://good-url.com/1", Enabled: true}},
+			wantSeeded: 2,
+			wantStart:  2,
+		},
+		{
+			name:      "Removed_Feeds",
+			seed:      feeds("https://good-url.com/1", "https://good-url.com/2"),
+			seeded:    true,
+			wantStart: 0,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -64,19 +105,56 @@ func TestParser_Start(t *testing.T) {
 			t.Parallel()
 
 			var parser = &Parser{
-				links:  tt.urls,
+				seed:   tt.seed,
 				period: time.Minute * 5,
 				client: &http.Client{
 					Transport: nil,
 					Timeout:   reqTime,
 				},
 				storage: nil,
-				done:    make(chan bool, len(tt.urls)),
 			}
 
+			stMock := mocks.NewDB(t)
+			stMock.
+				On("Feeds", mock.Anything).
+				Return(func(ctx context.Context) ([]storage.Feed, error) {
+					if tt.stored == nil {
+						return nil, storage.ErrNotFound
+					}
+					return tt.stored, nil
+				}).
+				Once()
+			stMock.
+				On("FeedsSeeded", mock.Anything).
+				Return(tt.seeded, nil).
+				Once()
+			if !tt.seeded {
+				stMock.
+					On("SetFeedsSeeded", mock.Anything).
+					Return(nil).
+					Once()
+			}
+			if tt.wantSeeded > 0 {
+				var ids int
+				stMock.
+					On("AddFeed", mock.Anything, mock.AnythingOfType("storage.Feed")).
+					Return(func(ctx context.Context, feed storage.Feed) (string, error) {
+						for _, st := range tt.stored {
+							if st.URL == feed.URL {
+								return "", storage.ErrDuplicate
+							}
+						}
+						ids++
+						return fmt.Sprint(ids), nil
+					}).
+					Times(tt.wantSeeded)
+			}
+			parser.storage = stMock
+
 			var reqCount int
-			// Создаем и настраиваем моки RoundTripper и хранилища, если
-			// ожидается успешный запуск хотя бы одного парсинга.
+			// Создаем и настраиваем мок RoundTripper и остальные методы мока
+			// хранилища, если ожидается успешный запуск хотя бы одного
+			// парсинга.
 			if tt.wantStart > 0 {
 				rtMock := mocks.NewRoundTripper(t)
 				rtMock.
@@ -99,20 +177,27 @@ func TestParser_Start(t *testing.T) {
 					Times(tt.wantStart)
 				parser.client.Transport = rtMock
 
-				stMock := mocks.NewDB(t)
+				// Состояние выключенной ленты тоже записывается в БД.
+				stMock.
+					On("FeedState", mock.Anything, mock.AnythingOfType("string")).
+					Return(storage.FeedState{}, storage.ErrNotFound).
+					Times(tt.wantStart + tt.wantDisabled)
+				stMock.
+					On("SetFeedState", mock.Anything, mock.AnythingOfType("storage.FeedState")).
+					Return(nil).
+					Times(tt.wantStart + tt.wantDisabled)
+				stMock.
+					On("AddSource", mock.Anything, mock.AnythingOfType("storage.Source")).
+					Return("1", nil).
+					Times(tt.wantStart)
 				stMock.
 					On("AddPosts", mock.Anything, mock.AnythingOfType("<-chan storage.Post")).
 					Return(2, nil).
 					Times(tt.wantStart)
-				parser.storage = stMock
 			}
 
 			err := parser.Start()
 			if err != nil {
-				// Если ошибка ожидаема, то успешно завершаем тест-кейс.
-				if tt.wantStart == 0 {
-					t.SkipNow()
-				}
 				t.Fatalf("Parser.Start() error = %v", err)
 			}
 
@@ -180,7 +265,6 @@ func TestParser_parseRSS(t *testing.T) {
 					Timeout:   reqTime,
 				},
 				storage: nil,
-				done:    make(chan bool, 1),
 			}
 
 			// Имитируем поведение RSS ресурса через содание мока интерфейса
@@ -217,10 +301,24 @@ func TestParser_parseRSS(t *testing.T) {
 
 			var count int
 
-			// Создаем и настраиваем мок хранилища только если планируем дойти
-			// до него в тестируемой функции.
+			// Состояние ленты запрашивается всегда, остальные методы мока
+			// хранилища настраиваем только если планируем дойти до них
+			// в тестируемой функции.
+			stMock := mocks.NewDB(t)
+			stMock.
+				On("FeedState", mock.Anything, tt.url).
+				Return(storage.FeedState{}, storage.ErrNotFound).
+				Once()
+			stMock.
+				On("SetFeedState", mock.Anything, mock.AnythingOfType("storage.FeedState")).
+				Return(nil).
+				Once()
+			parser.storage = stMock
 			if !tt.wantError || tt.mockError != nil {
-				stMock := mocks.NewDB(t)
+				stMock.
+					On("AddSource", mock.Anything, mock.AnythingOfType("storage.Source")).
+					Return("1", nil).
+					Once()
 				stMock.
 					On("AddPosts", mock.Anything, mock.AnythingOfType("<-chan storage.Post")).
 					Return(func(ctx context.Context, posts <-chan storage.Post) (int, error) {
@@ -234,13 +332,13 @@ func TestParser_parseRSS(t *testing.T) {
 						return count, nil
 					}).
 					Once()
-				parser.storage = stMock
 			}
 
-			go parser.parseRSS(tt.url)
+			ctx, cancel := context.WithCancel(context.Background())
+			go parser.parseRSS(ctx, storage.Feed{URL: tt.url, Enabled: true})
 
 			time.Sleep(time.Second * 5)
-			parser.Shutdown()
+			cancel()
 			if count != tt.wantCount {
 				t.Errorf("Parser.parseRSS() = %v, want = %v", count, tt.wantCount)
 			}
@@ -248,6 +346,293 @@ func TestParser_parseRSS(t *testing.T) {
 	}
 }
 
+// TestParser_fetch_Limits позволяет проверить, что при превышении
+// ограничений ленты посты, полученные до этого, записываются в БД,
+// а вызывающая сторона получает ошибку ограничения.
+func TestParser_fetch_Limits(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	feed, err := os.ReadFile("testFeed.xml")
+	if err != nil {
+		t.Fatalf("Parser.fetch() error = cannot read test XML feed")
+	}
+
+	rtMock := mocks.NewRoundTripper(t)
+	rtMock.
+		On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) (*http.Response, error) {
+			resp := &http.Response{
+				Status:        "200 OK",
+				StatusCode:    200,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Body:          io.NopCloser(bytes.NewBuffer(feed)),
+				ContentLength: int64(len(feed)),
+				Request:       req,
+				Header:        make(http.Header),
+			}
+			return resp, nil
+		}).
+		Once()
+
+	var count int
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("AddSource", mock.Anything, mock.AnythingOfType("storage.Source")).
+		Return("1", nil).
+		Once()
+	stMock.
+		On("AddPosts", mock.Anything, mock.AnythingOfType("<-chan storage.Post")).
+		Return(func(ctx context.Context, posts <-chan storage.Post) (int, error) {
+			for range posts {
+				count++
+			}
+			return count, nil
+		}).
+		Once()
+
+	var parser = &Parser{
+		client: &http.Client{
+			Transport: rtMock,
+			Timeout:   reqTime,
+		},
+		limits:  rss.Limits{MaxItems: 1},
+		storage: stMock,
+	}
+
+	req, err := http.NewRequest("GET", "https://good-url.com", nil)
+	if err != nil {
+		t.Fatalf("Parser.fetch() error = cannot create request")
+	}
+
+	num, err := parser.fetch(context.Background(), req, storage.Feed{URL: "https://good-url.com"}, &storage.FeedState{})
+	if !errors.Is(err, rss.ErrLimitExceeded) {
+		t.Errorf("Parser.fetch() error = %v, want %v", err, rss.ErrLimitExceeded)
+	}
+	if num != 1 || count != 1 {
+		t.Errorf("Parser.fetch() = %v, want %v", num, 1)
+	}
+}
+
+// TestParser_fetch_NotModified позволяет проверить условные запросы:
+// заголовки ETag и Last-Modified первого ответа сохраняются в состоянии
+// ленты и передаются в следующем запросе, а ответ 304 Not Modified
+// не доходит до декодирования и записи постов.
+func TestParser_fetch_NotModified(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	feed, err := os.ReadFile("testFeed.xml")
+	if err != nil {
+		t.Fatalf("Parser.fetch() error = cannot read test XML feed")
+	}
+
+	const (
+		etag     = `"5f1b2c"`
+		modified = "Thu, 25 Jul 2024 05:41:25 GMT"
+	)
+
+	rtMock := mocks.NewRoundTripper(t)
+	rtMock.
+		On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) (*http.Response, error) {
+			resp := &http.Response{
+				Proto:      "HTTP/1.1",
+				ProtoMajor: 1,
+				ProtoMinor: 1,
+				Request:    req,
+				Header:     make(http.Header),
+			}
+			if req.Header.Get("If-None-Match") == etag &&
+				req.Header.Get("If-Modified-Since") == modified {
+				resp.Status = "304 Not Modified"
+				resp.StatusCode = 304
+				resp.Body = http.NoBody
+				return resp, nil
+			}
+			resp.Status = "200 OK"
+			resp.StatusCode = 200
+			resp.Body = io.NopCloser(bytes.NewBuffer(feed))
+			resp.ContentLength = int64(len(feed))
+			resp.Header.Set("ETag", etag)
+			resp.Header.Set("Last-Modified", modified)
+			return resp, nil
+		}).
+		Twice()
+
+	want := storage.FeedState{URL: "https://good-url.com", ETag: etag, LastModified: modified}
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("AddSource", mock.Anything, mock.AnythingOfType("storage.Source")).
+		Return("1", nil).
+		Once()
+	stMock.
+		On("AddPosts", mock.Anything, mock.AnythingOfType("<-chan storage.Post")).
+		Return(func(ctx context.Context, posts <-chan storage.Post) (int, error) {
+			var count int
+			for range posts {
+				count++
+			}
+			return count, nil
+		}).
+		Once()
+
+	var parser = &Parser{
+		client: &http.Client{
+			Transport: rtMock,
+			Timeout:   reqTime,
+		},
+		storage: stMock,
+	}
+
+	req, err := http.NewRequest("GET", want.URL, nil)
+	if err != nil {
+		t.Fatalf("Parser.fetch() error = cannot create request")
+	}
+	state := storage.FeedState{URL: want.URL}
+
+	num, err := parser.fetch(context.Background(), req, storage.Feed{URL: want.URL}, &state)
+	if err != nil {
+		t.Fatalf("Parser.fetch() error = %v", err)
+	}
+	if num != 2 {
+		t.Errorf("Parser.fetch() = %v, want %v", num, 2)
+	}
+	if state != want {
+		t.Errorf("Parser.fetch() state = %+v, want %+v", state, want)
+	}
+
+	num, err = parser.fetch(context.Background(), req, storage.Feed{URL: want.URL}, &state)
+	if !errors.Is(err, ErrNotModified) {
+		t.Errorf("Parser.fetch() error = %v, want %v", err, ErrNotModified)
+	}
+	if num != 0 {
+		t.Errorf("Parser.fetch() = %v, want %v", num, 0)
+	}
+}
+
+// TestParser_parseRSS_NotModified позволяет проверить, что ответ
+// 304 Not Modified учитывается в сохраняемом состоянии ленты.
+func TestParser_parseRSS_NotModified(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	const url = "https://good-url.com"
+
+	rtMock := mocks.NewRoundTripper(t)
+	rtMock.
+		On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Status:     "304 Not Modified",
+			StatusCode: 304,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+		}, nil).
+		Once()
+
+	saved := make(chan storage.FeedState, 1)
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("FeedState", mock.Anything, url).
+		Return(storage.FeedState{URL: url, ETag: `"5f1b2c"`, NotModified: 2}, nil).
+		Once()
+	stMock.
+		On("SetFeedState", mock.Anything, mock.AnythingOfType("storage.FeedState")).
+		Return(func(ctx context.Context, st storage.FeedState) error {
+			saved <- st
+			return nil
+		}).
+		Once()
+
+	var parser = &Parser{
+		period: time.Minute * 5,
+		client: &http.Client{
+			Transport: rtMock,
+			Timeout:   reqTime,
+		},
+		storage: stMock,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go parser.parseRSS(ctx, storage.Feed{URL: url, Enabled: true})
+
+	select {
+	case st := <-saved:
+		if st.NotModified != 3 {
+			t.Errorf("Parser.parseRSS() notModified = %v, want %v", st.NotModified, 3)
+		}
+		if st.Health != storage.HealthHealthy {
+			t.Errorf("Parser.parseRSS() health = %v, want %v", st.Health, storage.HealthHealthy)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("Parser.parseRSS() error = feed state was not saved")
+	}
+}
+
+// Test_newRequest позволяет проверить заголовки и учетные данные
+// запроса из настроек ленты, в том числе из переменных окружения.
+func Test_newRequest(t *testing.T) {
+	t.Setenv("TEST_PARSER_TOKEN", "env-secret")
+
+	tests := []struct {
+		name string
+		feed storage.Feed
+		want http.Header
+	}{
+		{
+			name: "Headers",
+			feed: storage.Feed{
+				URL:     "https://good-url.com",
+				Headers: map[string]string{"accept": "application/rss+xml"},
+			},
+			want: http.Header{"Accept": {"application/rss+xml"}},
+		},
+		{
+			name: "Basic_Auth",
+			feed: storage.Feed{
+				URL:  "https://good-url.com",
+				Auth: storage.FeedAuth{Username: "user", Password: "passwd"},
+			},
+			want: http.Header{"Authorization": {"Basic dXNlcjpwYXNzd2Q="}},
+		},
+		{
+			name: "Bearer_Auth",
+			feed: storage.Feed{
+				URL:  "https://good-url.com",
+				Auth: storage.FeedAuth{Token: "secret"},
+			},
+			want: http.Header{"Authorization": {"Bearer secret"}},
+		},
+		{
+			name: "Auth_From_Env",
+			feed: storage.Feed{
+				URL:  "https://good-url.com",
+				Auth: storage.FeedAuth{Token: "${TEST_PARSER_TOKEN}"},
+			},
+			want: http.Header{"Authorization": {"Bearer env-secret"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := newRequest(tt.feed)
+			if err != nil {
+				t.Fatalf("newRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(req.Header, tt.want) {
+				t.Errorf("newRequest() header = %v, want %v", req.Header, tt.want)
+			}
+		})
+	}
+}
+
 func Test_postConv(t *testing.T) {
 	t.Parallel()
 
@@ -261,41 +646,74 @@ func Test_postConv(t *testing.T) {
 	if err != nil {
 		t.Fatalf("postConv() error = cannot decode RSS feed")
 	}
-	var feedEmpty rss.Feed
 
 	tests := []struct {
-		name string
-		feed rss.Feed
-		want int
+		name  string
+		items []rss.Item
+		want  int
 	}{
 		// Значение want должно совпасть с количеством элементов Item
 		// в структуре rss.Feed.
 		{
-			name: "PostConv_OK",
-			feed: feedOK,
-			want: 2,
+			name:  "PostConv_OK",
+			items: feedOK.Channel.Items,
+			want:  2,
 		},
 		{
-			name: "PostConv_Empty_Feed",
-			feed: feedEmpty,
-			want: 0,
+			name:  "PostConv_Empty_Feed",
+			items: nil,
+			want:  0,
 		},
 	}
 	for _, tt := range tests {
 		tt := tt
 
 		t.Run(tt.name, func(t *testing.T) {
-			posts := postConv(tt.feed)
-			if tt.want == 0 {
-				if posts == nil {
-					t.SkipNow()
-				}
-				t.Fatalf("postConv() = unexpected nil")
+			items := make(chan rss.Item, len(tt.items))
+			for _, i := range tt.items {
+				items <- i
 			}
+			close(items)
+
+			canon := canonical.New(nil)
+			posts := postConv(items, "1", "en", func(link string) string {
+				return canon.URL(link, "https://habr.com/ru/hubs/go/articles/")
+			})
 
 			var got int
 			for p := range posts {
-				_ = p
+				// Параметры отслеживания удаляются из ссылок.
+				if strings.Contains(p.Link, "utm_") || !strings.HasPrefix(p.Link, "https://habr.com/ru/") {
+					t.Errorf("postConv() link = %v, want canonical link", p.Link)
+				}
+				// Идентификатор и автор есть у каждого поста тестовой ленты.
+				if p.GUID == "" || p.Author == "" {
+					t.Errorf("postConv() post = %+v, want GUID and Author", p)
+				}
+				if p.SourceID != "1" {
+					t.Errorf("postConv() sourceId = %v, want %v", p.SourceID, "1")
+				}
+				// У первого поста тестовой ленты изображение во вложении,
+				// у второго изображения нет.
+				wantImage := ""
+				if strings.Contains(p.Link, "831252") {
+					wantImage = "https://habrastorage.org/getpro/habr/upload_files/0ea/8be/7bc/0ea8be7bcdd15e01a22aa60ade414a24.jpg"
+				}
+				if p.Image != wantImage {
+					t.Errorf("postConv() image = %v, want %v", p.Image, wantImage)
+				}
+				// Язык постов определяется по тексту, а не по языку канала.
+				if p.Lang != "ru" {
+					t.Errorf("postConv() lang = %v, want %v", p.Lang, "ru")
+				}
+				// Сигнатура вычисляется по заголовку и тексту поста.
+				if want := minhash.Signature(p.Title, p.Content); !reflect.DeepEqual(p.MinHash, want) {
+					t.Errorf("postConv() minHash = %v, want %v", p.MinHash, want)
+				}
+				// Разметка описания сохраняется в очищенном HTML.
+				if !strings.Contains(p.ContentHTML, "<p>") || strings.Contains(p.ContentHTML, "utm_") || strings.Contains(p.Content, "<p>") {
+					t.Errorf("postConv() content = %q, html = %q, want plain text and sanitized HTML", p.Content, p.ContentHTML)
+				}
 				got++
 			}
 			if got != tt.want {
@@ -312,28 +730,75 @@ func Test_timeConv(t *testing.T) {
 	unix := tm.Unix()
 
 	tests := []struct {
-		name string
-		time string
-		want int64
+		name    string
+		time    string
+		want    int64
+		guessed bool
 	}{
 		{
-			name: "RFC1123",
-			time: "Sat, 27 Jul 2024 00:00:00 UTC",
-			want: unix,
+			name:    "RFC1123",
+			time:    "Sat, 27 Jul 2024 00:00:00 UTC",
+			want:    unix,
+			guessed: false,
+		},
+		{
+			name:    "RFC1123Z",
+			time:    "Sat, 27 Jul 2024 00:00:00 +0000",
+			want:    unix,
+			guessed: false,
+		},
+		{
+			name:    "RFC3339",
+			time:    "2024-07-27T03:00:00+03:00",
+			want:    unix,
+			guessed: false,
+		},
+		{
+			name:    "Named_Zone",
+			time:    "Sat, 27 Jul 2024 03:00 MSK",
+			want:    unix,
+			guessed: false,
 		},
 		{
-			name: "RFC1123Z",
-			time: "Sat, 27 Jul 2024 00:00:00 +0000",
-			want: unix,
+			name:    "Incorrect",
+			time:    "27/07/2024",
+			want:    0,
+			guessed: true,
+		},
+		{
+			name:    "Future",
+			time:    time.Now().AddDate(1, 0, 0).Format(time.RFC1123Z),
+			want:    0,
+			guessed: true,
 		},
 	}
 	for _, tt := range tests {
 		tt := tt
 
 		t.Run(tt.name, func(t *testing.T) {
-			if got := timeConv(tt.time).Unix(); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("timeConv() = %v, want %v", got, tt.want)
+			got, guessed := timeConv(tt.time)
+			if guessed != tt.guessed {
+				t.Fatalf("timeConv() guessed = %v, want %v", guessed, tt.guessed)
+			}
+			// Для угаданной даты проверяем, что подставлено текущее время.
+			if tt.guessed {
+				if time.Since(got) > time.Minute {
+					t.Errorf("timeConv() = %v, want current time", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got.Unix(), tt.want) {
+				t.Errorf("timeConv() = %v, want %v", got.Unix(), tt.want)
 			}
 		})
 	}
 }
+
+// feeds возвращает список включенных лент с переданными адресами.
+func feeds(urls ...string) []storage.Feed {
+	res := make([]storage.Feed, 0, len(urls))
+	for _, url := range urls {
+		res = append(res, storage.Feed{URL: url, Enabled: true})
+	}
+	return res
+}
@@ -0,0 +1,178 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/logger"
+	"GoNews/internal/rss"
+	"GoNews/internal/storage"
+	"GoNews/internal/websub"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// pushPeriod - период контрольного опроса ленты с активной подпиской
+// WebSub. Опрос нужен, чтобы заметить, что хаб перестал доставлять
+// обновления.
+const pushPeriod time.Duration = time.Hour * 6
+
+// resubscribeAfter - время, через которое повторяется запрос подписки,
+// если хаб ее не подтвердил или отказал в ней.
+const resubscribeAfter time.Duration = time.Hour * 24
+
+// hubLinks возвращает адрес хаба WebSub и адрес ленты для подписки.
+// Ссылки из заголовка Link ответа имеют приоритет над ссылками внутри
+// ленты. Если хаб не объявлен, возвращает пустые строки.
+func hubLinks(h http.Header, ch rss.Channel, feedURL string) (hub, topic string) {
+	hub, topic = websub.Links(h)
+	if hub == "" {
+		hub = ch.Hub
+	}
+	if hub == "" {
+		return "", ""
+	}
+	if topic == "" {
+		topic = ch.Self
+	}
+	if topic == "" {
+		topic = feedURL
+	}
+	return hub, topic
+}
+
+// push поддерживает подписку WebSub на ленту после успешного опроса.
+// Подписка оформляется, если ее нет или изменился хаб, продлевается
+// перед окончанием срока аренды и повторяется, если хаб ее
+// не подтвердил. Если опрос нашел посты, которые хаб не доставил,
+// подписка считается устаревшей и лента опрашивается в обычном режиме.
+// Пока подписка активна, следующий опрос откладывается на pushPeriod.
+func (p *Parser) push(ctx context.Context, feed storage.Feed, state *storage.FeedState, num int, period time.Duration) {
+	url := feed.URL
+
+	sub, err := p.storage.Subscription(ctx, feed.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		slog.Error("cannot receive subscription", slog.String("url", url), logger.Err(err))
+		return
+	}
+	if err != nil || sub.Hub != state.Hub || sub.Topic != state.Topic {
+		sub = storage.Subscription{FeedID: feed.ID, Hub: state.Hub, Topic: state.Topic}
+	}
+
+	now := time.Now()
+	active := sub.State == storage.SubscriptionActive && now.Before(sub.LeaseExpires)
+	if active && num > 0 {
+		slog.Warn("hub missed feed updates", slog.String("url", url), slog.String("hub", sub.Hub))
+		sub.State = storage.SubscriptionStale
+		active = false
+		err = p.storage.SetSubscription(ctx, sub)
+		if err != nil {
+			slog.Error("cannot save subscription", slog.String("url", url), logger.Err(err))
+		}
+	}
+
+	var subscribe bool
+	switch {
+	case sub.Requested.IsZero():
+		subscribe = true
+	case active:
+		lease := time.Duration(sub.LeaseSeconds) * time.Second
+		subscribe = time.Until(sub.LeaseExpires) < lease/5
+	default:
+		subscribe = now.Sub(sub.Requested) > resubscribeAfter
+	}
+
+	if subscribe {
+		subCtx, cancel := context.WithTimeout(ctx, reqTime)
+		sub, err = p.websub.Subscribe(subCtx, sub)
+		cancel()
+		if err != nil {
+			slog.Error("cannot subscribe to hub", slog.String("url", url), slog.String("hub", sub.Hub), logger.Err(err))
+		} else {
+			slog.Info("subscription requested", slog.String("url", url), slog.String("hub", sub.Hub))
+		}
+	}
+
+	if active {
+		state.NextAttempt = now.Add(max(pushPeriod, period))
+	}
+}
+
+// Verify обрабатывает запрос хаба на подтверждение подписки на ленту
+// с ID id. Возвращает значение для ответа хабу. Если подписки WebSub
+// выключены, возвращает ErrNoWebSub.
+func (p *Parser) Verify(ctx context.Context, id string, query url.Values) (string, error) {
+	const operation = "parser.Verify"
+
+	if p.websub == nil {
+		return "", fmt.Errorf("%s: %w", operation, ErrNoWebSub)
+	}
+	challenge, err := p.websub.Verify(ctx, id, query)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", operation, err)
+	}
+	return challenge, nil
+}
+
+// Deliver записывает в БД посты из обновления ленты с ID id,
+// доставленного хабом. Обновление принимается, только если его подпись
+// signature совпадает с ключом подписки. Обновления выключенных лент
+// пропускаются. Обновления лент с загрузкой полного текста статей
+// записываются в отдельной горутине опроса ленты, чтобы загрузка
+// статей не задерживала ответ хабу. Если подписки WebSub выключены,
+// возвращает ErrNoWebSub.
+func (p *Parser) Deliver(ctx context.Context, id, signature string, body []byte, contentType string) error {
+	const operation = "parser.Deliver"
+
+	if p.websub == nil {
+		return fmt.Errorf("%s: %w", operation, ErrNoWebSub)
+	}
+	sub, err := p.websub.Check(ctx, id, signature, body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	feed, err := p.storage.FeedById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	if !feed.Enabled {
+		slog.Debug("update of disabled feed skipped", slog.String("url", feed.URL))
+		return nil
+	}
+
+	if feed.FullText {
+		ok := p.background(feed.ID, func(ctx context.Context) {
+			err := p.deliver(ctx, feed, sub, body, contentType)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("cannot store feed update", slog.String("url", feed.URL), logger.Err(err))
+			}
+		})
+		if ok {
+			return nil
+		}
+	}
+
+	err = p.deliver(ctx, feed, sub, body, contentType)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	return nil
+}
+
+// deliver записывает в БД посты из обновления ленты, доставленного
+// хабом, и отмечает время доставки в подписке sub.
+func (p *Parser) deliver(ctx context.Context, feed storage.Feed, sub storage.Subscription, body []byte, contentType string) error {
+	_, num, err := p.store(ctx, feed, bytes.NewReader(body), contentType)
+	if err != nil && !errors.Is(err, rss.ErrLimitExceeded) {
+		return err
+	}
+	slog.Info("Posts from hub added successfully", slog.Int("posts", num), slog.String("url", feed.URL))
+
+	sub.LastDelivery = time.Now()
+	return p.storage.SetSubscription(ctx, sub)
+}
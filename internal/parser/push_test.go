@@ -0,0 +1,385 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/logger"
+	"GoNews/internal/mocks"
+	"GoNews/internal/rss"
+	"GoNews/internal/storage"
+	"GoNews/internal/websub"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testHub   = "https://pubsubhubbub.appspot.com/"
+	testTopic = "https://good-url.com/feed.atom"
+)
+
+func Test_hubLinks(t *testing.T) {
+	t.Parallel()
+
+	header := make(http.Header)
+	header.Set("Link", `<https://hub.good-url.com/>; rel="hub"`)
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		ch        rss.Channel
+		wantHub   string
+		wantTopic string
+	}{
+		{
+			name:      "Channel",
+			header:    make(http.Header),
+			ch:        rss.Channel{Hub: testHub, Self: testTopic},
+			wantHub:   testHub,
+			wantTopic: testTopic,
+		},
+		{
+			// Хаб из заголовка имеет приоритет, адрес ленты берется
+			// из канала.
+			name:      "Header",
+			header:    header,
+			ch:        rss.Channel{Hub: testHub, Self: testTopic},
+			wantHub:   "https://hub.good-url.com/",
+			wantTopic: testTopic,
+		},
+		{
+			name:      "No_Self",
+			header:    make(http.Header),
+			ch:        rss.Channel{Hub: testHub},
+			wantHub:   testHub,
+			wantTopic: "https://good-url.com",
+		},
+		{
+			name:   "No_Hub",
+			header: make(http.Header),
+			ch:     rss.Channel{Self: testTopic},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hub, topic := hubLinks(tt.header, tt.ch, "https://good-url.com")
+			if hub != tt.wantHub || topic != tt.wantTopic {
+				t.Errorf("hubLinks() = %v, %v, want %v, %v", hub, topic, tt.wantHub, tt.wantTopic)
+			}
+		})
+	}
+}
+
+func TestParser_push(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	now := time.Now()
+	active := storage.Subscription{
+		FeedID:       "1",
+		Hub:          testHub,
+		Topic:        testTopic,
+		Secret:       "secret",
+		State:        storage.SubscriptionActive,
+		LeaseSeconds: 10 * 24 * 60 * 60,
+		LeaseExpires: now.Add(9 * 24 * time.Hour),
+		Requested:    now.Add(-time.Hour),
+	}
+	expiring := active
+	expiring.LeaseExpires = now.Add(time.Hour)
+	pending := active
+	pending.State = storage.SubscriptionPending
+	denied := active
+	denied.State = storage.SubscriptionDenied
+	denied.Requested = now.Add(-48 * time.Hour)
+	otherHub := active
+	otherHub.Hub = "https://hub.good-url.com/"
+
+	tests := []struct {
+		name   string
+		sub    storage.Subscription
+		subErr error
+		num    int
+		// wantSubscribe - ожидается запрос подписки в хаб.
+		wantSubscribe bool
+		// wantStale - подписка отмечается устаревшей.
+		wantStale bool
+		// wantDelayed - следующий опрос откладывается на pushPeriod.
+		wantDelayed bool
+	}{
+		{
+			name:          "New",
+			subErr:        storage.ErrNotFound,
+			wantSubscribe: true,
+		},
+		{
+			name:        "Active",
+			sub:         active,
+			wantDelayed: true,
+		},
+		{
+			name:          "Renew",
+			sub:           expiring,
+			wantSubscribe: true,
+			wantDelayed:   true,
+		},
+		{
+			// Хаб не доставил посты, найденные опросом.
+			name:      "Stale",
+			sub:       active,
+			num:       2,
+			wantStale: true,
+		},
+		{
+			name: "Pending",
+			sub:  pending,
+		},
+		{
+			name:          "Denied",
+			sub:           denied,
+			wantSubscribe: true,
+		},
+		{
+			name:          "Hub_Changed",
+			sub:           otherHub,
+			wantSubscribe: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stMock := mocks.NewDB(t)
+			stMock.
+				On("Subscription", mock.Anything, "1").
+				Return(tt.sub, tt.subErr).
+				Once()
+			var saved []storage.Subscription
+			if tt.wantSubscribe || tt.wantStale {
+				stMock.
+					On("SetSubscription", mock.Anything, mock.AnythingOfType("storage.Subscription")).
+					Return(func(ctx context.Context, sub storage.Subscription) error {
+						saved = append(saved, sub)
+						return nil
+					})
+			}
+
+			rtMock := mocks.NewRoundTripper(t)
+			if tt.wantSubscribe {
+				rtMock.
+					On("RoundTrip", mock.AnythingOfType("*http.Request")).
+					Return(&http.Response{
+						StatusCode: http.StatusAccepted,
+						Status:     "202 Accepted",
+						Body:       http.NoBody,
+						Header:     make(http.Header),
+					}, nil).
+					Once()
+			}
+
+			client := &http.Client{Transport: rtMock}
+			parser := &Parser{
+				client:  client,
+				storage: stMock,
+				websub:  websub.New(client, "https://news.good-url.com", stMock),
+			}
+
+			next := now.Add(time.Minute)
+			state := storage.FeedState{URL: "https://good-url.com", Hub: testHub, Topic: testTopic, NextAttempt: next}
+			parser.push(context.Background(), storage.Feed{ID: "1", URL: state.URL}, &state, tt.num, time.Minute)
+
+			if tt.wantStale && (len(saved) == 0 || saved[0].State != storage.SubscriptionStale) {
+				t.Errorf("Parser.push() saved = %+v, want stale subscription", saved)
+			}
+			if tt.wantSubscribe && (len(saved) == 0 || saved[len(saved)-1].Hub != testHub) {
+				t.Errorf("Parser.push() saved = %+v, want subscription to %v", saved, testHub)
+			}
+			delayed := state.NextAttempt.Sub(now) >= pushPeriod
+			if delayed != tt.wantDelayed {
+				t.Errorf("Parser.push() next attempt = %v, delayed %v, want %v", state.NextAttempt, delayed, tt.wantDelayed)
+			}
+		})
+	}
+}
+
+func TestParser_Deliver(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	body, err := os.ReadFile("testFeed.xml")
+	if err != nil {
+		t.Fatalf("Parser.Deliver() error = cannot read test XML feed")
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	sub := storage.Subscription{FeedID: "1", Hub: testHub, Topic: testTopic, Secret: "secret", State: storage.SubscriptionActive}
+
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("Subscription", mock.Anything, "1").
+		Return(sub, nil).
+		Twice()
+	stMock.
+		On("FeedById", mock.Anything, "1").
+		Return(storage.Feed{ID: "1", URL: "https://good-url.com", Enabled: true}, nil).
+		Once()
+	stMock.
+		On("AddSource", mock.Anything, mock.AnythingOfType("storage.Source")).
+		Return("1", nil).
+		Once()
+	stMock.
+		On("AddPosts", mock.Anything, mock.AnythingOfType("<-chan storage.Post")).
+		Return(func(ctx context.Context, posts <-chan storage.Post) (int, error) {
+			var count int
+			for range posts {
+				count++
+			}
+			return count, nil
+		}).
+		Once()
+	var saved storage.Subscription
+	stMock.
+		On("SetSubscription", mock.Anything, mock.AnythingOfType("storage.Subscription")).
+		Return(func(ctx context.Context, sub storage.Subscription) error {
+			saved = sub
+			return nil
+		}).
+		Once()
+
+	parser := &Parser{
+		client:  http.DefaultClient,
+		storage: stMock,
+		websub:  websub.New(http.DefaultClient, "https://news.good-url.com", stMock),
+	}
+
+	err = parser.Deliver(context.Background(), "1", signature, body, "application/rss+xml")
+	if err != nil {
+		t.Fatalf("Parser.Deliver() error = %v", err)
+	}
+	if saved.LastDelivery.IsZero() {
+		t.Errorf("Parser.Deliver() last delivery is not set")
+	}
+
+	// Обновление с чужой подписью не записывается.
+	forged := []byte(strings.Replace(string(body), "<title>", "<title>Forged ", 1))
+	err = parser.Deliver(context.Background(), "1", signature, forged, "application/rss+xml")
+	if !errors.Is(err, websub.ErrBadSignature) {
+		t.Errorf("Parser.Deliver() error = %v, want %v", err, websub.ErrBadSignature)
+	}
+
+	// Без внешнего адреса сервера подписки выключены.
+	err = (&Parser{storage: stMock}).Deliver(context.Background(), "1", signature, body, "application/rss+xml")
+	if !errors.Is(err, ErrNoWebSub) {
+		t.Errorf("Parser.Deliver() error = %v, want %v", err, ErrNoWebSub)
+	}
+	_, err = (&Parser{storage: stMock}).Verify(context.Background(), "1", nil)
+	if !errors.Is(err, ErrNoWebSub) {
+		t.Errorf("Parser.Verify() error = %v, want %v", err, ErrNoWebSub)
+	}
+}
+
+// TestParser_Deliver_fullText проверяет, что обновление ленты
+// с загрузкой полного текста записывается после ответа хабу.
+func TestParser_Deliver_fullText(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	body, err := os.ReadFile("testFeed.xml")
+	if err != nil {
+		t.Fatalf("Parser.Deliver() error = cannot read test XML feed")
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	sub := storage.Subscription{FeedID: "1", Hub: testHub, Topic: testTopic, Secret: "secret", State: storage.SubscriptionActive}
+	feed := storage.Feed{ID: "1", URL: "https://good-url.com", Enabled: true, FullText: true}
+
+	// Статьи не загружаются, в постах остается описание из ленты.
+	rtMock := mocks.NewRoundTripper(t)
+	rtMock.
+		On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: http.NoBody}, nil).
+		Maybe()
+
+	release := make(chan struct{})
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("Subscription", mock.Anything, "1").
+		Return(sub, nil).
+		Once()
+	stMock.
+		On("PostByKey", mock.Anything, "1", mock.AnythingOfType("string")).
+		Return(storage.Post{}, storage.ErrNotFound).
+		Maybe()
+	stMock.
+		On("FeedById", mock.Anything, "1").
+		Return(feed, nil).
+		Once()
+	stMock.
+		On("AddSource", mock.Anything, mock.AnythingOfType("storage.Source")).
+		Return("1", nil).
+		Once()
+	stMock.
+		On("AddPosts", mock.Anything, mock.AnythingOfType("<-chan storage.Post")).
+		Return(func(ctx context.Context, posts <-chan storage.Post) (int, error) {
+			<-release
+			var count int
+			for range posts {
+				count++
+			}
+			return count, nil
+		}).
+		Once()
+	var saved storage.Subscription
+	stMock.
+		On("SetSubscription", mock.Anything, mock.AnythingOfType("storage.Subscription")).
+		Return(func(ctx context.Context, sub storage.Subscription) error {
+			saved = sub
+			return nil
+		}).
+		Once()
+
+	client := &http.Client{Transport: rtMock}
+	parser := &Parser{
+		client:  client,
+		storage: stMock,
+		hosts:   newHostLimiter(0),
+		websub:  websub.New(client, "https://news.good-url.com", stMock),
+	}
+	parser.articles.feeds = make(map[string]map[string]article)
+
+	// Опрос ленты запущен, но сама лента не запрашивается.
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{feed: feed, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	close(w.done)
+	parser.workers = map[string]*worker{"1": w}
+
+	err = parser.Deliver(context.Background(), "1", signature, body, "application/rss+xml")
+	if err != nil {
+		t.Fatalf("Parser.Deliver() error = %v", err)
+	}
+	close(release)
+
+	w.pushes.Wait()
+	if saved.LastDelivery.IsZero() {
+		t.Errorf("Parser.Deliver() last delivery is not set")
+	}
+	parser.Shutdown()
+}
@@ -0,0 +1,152 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/config"
+	"GoNews/internal/storage"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tagRule - правило разметки постов тегом, подготовленное для
+// проверки постов. Ключевые слова, источники и категории хранятся
+// в нижнем регистре.
+type tagRule struct {
+	tag        string
+	keywords   []string
+	patterns   []*regexp.Regexp
+	sources    map[string]bool
+	categories map[string]bool
+}
+
+// tagRules преобразует правила разметки из конфига. Теги приводятся
+// к нижнему регистру, правила с одинаковым тегом не объединяются,
+// но тег поста не повторяется.
+func tagRules(cfg []config.TagRule) ([]tagRule, error) {
+	rules := make([]tagRule, 0, len(cfg))
+	for _, c := range cfg {
+		r := tagRule{
+			tag:        strings.ToLower(strings.TrimSpace(c.Tag)),
+			sources:    make(map[string]bool),
+			categories: make(map[string]bool),
+		}
+		if r.tag == "" {
+			return nil, errors.New("empty tag")
+		}
+		for _, k := range c.Keywords {
+			if k = strings.ToLower(strings.TrimSpace(k)); k != "" && k != "*" {
+				r.keywords = append(r.keywords, k)
+			}
+		}
+		for _, p := range c.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("tag %q: %w", r.tag, err)
+			}
+			r.patterns = append(r.patterns, re)
+		}
+		for _, s := range c.Sources {
+			r.sources[strings.ToLower(strings.TrimSpace(s))] = true
+		}
+		for _, cat := range c.Categories {
+			r.categories[strings.ToLower(strings.TrimSpace(cat))] = true
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// tag назначает постам из канала posts теги по правилам разметки
+// парсера и отправляет посты в возвращаемый канал.
+func (p *Parser) tag(feed storage.Feed, posts <-chan storage.Post) <-chan storage.Post {
+	out := make(chan storage.Post)
+
+	go func() {
+		defer close(out)
+		for post := range posts {
+			post.Tags = postTags(post, feed, p.tags)
+			out <- post
+		}
+	}()
+
+	return out
+}
+
+// postTags возвращает отсортированный список тегов поста из ленты feed
+// по правилам rules. Если ни одно правило не подошло, возвращает nil.
+func postTags(post storage.Post, feed storage.Feed, rules []tagRule) []string {
+	text := strings.ToLower(post.Title + "\n" + post.Content)
+	source := strings.ToLower(feed.URL)
+	name := strings.ToLower(strings.TrimSpace(feed.Name))
+
+	var tags []string
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if seen[r.tag] || !r.match(post, text, source, name) {
+			continue
+		}
+		seen[r.tag] = true
+		tags = append(tags, r.tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// match сообщает, что пост подходит под правило. text - заголовок
+// и текст поста в нижнем регистре, source и name - адрес и название
+// ленты в нижнем регистре.
+func (r tagRule) match(post storage.Post, text, source, name string) bool {
+	if r.sources[source] || (name != "" && r.sources[name]) {
+		return true
+	}
+	for _, c := range post.Categories {
+		if r.categories[strings.ToLower(strings.TrimSpace(c))] {
+			return true
+		}
+	}
+	for _, k := range r.keywords {
+		if hasKeyword(text, k) {
+			return true
+		}
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(post.Title) || re.MatchString(post.Content) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasKeyword сообщает, что в тексте есть ключевое слово целым словом.
+// Ключевое слово с "*" в конце задает начало слова.
+func hasKeyword(text, keyword string) bool {
+	prefix := strings.HasSuffix(keyword, "*")
+	keyword = strings.TrimSuffix(keyword, "*")
+
+	for start := 0; ; {
+		i := strings.Index(text[start:], keyword)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(keyword)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (i == 0 || !isWordRune(before)) && (prefix || end == len(text) || !isWordRune(after)) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		start = i + size
+	}
+}
+
+// isWordRune сообщает, что символ является частью слова.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
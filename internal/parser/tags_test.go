@@ -0,0 +1,85 @@
+// Пакет парсера RSS лент.
+package parser
+
+import (
+	"GoNews/internal/config"
+	"GoNews/internal/storage"
+	"reflect"
+	"testing"
+)
+
+func Test_postTags(t *testing.T) {
+	t.Parallel()
+
+	rules, err := tagRules([]config.TagRule{
+		{Tag: "Generics", Keywords: []string{"generics", "дженерик*"}},
+		{Tag: "concurrency", Keywords: []string{"горутин*"}, Patterns: []string{`(?i)\bchan(nel)?s?\b`}},
+		{Tag: "kubernetes", Keywords: []string{"k8s", "kubernetes"}, Categories: []string{"Kubernetes"}},
+		{Tag: "weekly", Sources: []string{"https://golangweekly.com/rss", "Хабр: лучшее"}},
+		{Tag: "generics", Categories: []string{"Go"}},
+	})
+	if err != nil {
+		t.Fatalf("tagRules() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		post storage.Post
+		feed storage.Feed
+		want []string
+	}{
+		{
+			name: "Keywords",
+			post: storage.Post{Title: "Дженерики в Go 1.18", Content: "Горутины и каналы"},
+			want: []string{"concurrency", "generics"},
+		},
+		{
+			name: "Whole_Words",
+			post: storage.Post{Title: "Nongenerics and k8sx", Content: "changes in channelz"},
+			want: nil,
+		},
+		{
+			name: "Pattern",
+			post: storage.Post{Title: "Closing a Channel", Content: "..."},
+			want: []string{"concurrency"},
+		},
+		{
+			name: "Category",
+			post: storage.Post{Title: "Деплой", Categories: []string{" kubernetes ", "go"}},
+			want: []string{"generics", "kubernetes"},
+		},
+		{
+			name: "Source_URL",
+			post: storage.Post{Title: "Issue #500"},
+			feed: storage.Feed{URL: "https://golangweekly.com/rss"},
+			want: []string{"weekly"},
+		},
+		{
+			name: "Source_Name",
+			post: storage.Post{Title: "Лучшее за сутки"},
+			feed: storage.Feed{URL: "https://habr.com/ru/rss/best/daily/", Name: "хабр: Лучшее"},
+			want: []string{"weekly"},
+		},
+		{
+			name: "No_Tags",
+			post: storage.Post{Title: "Go 1.23", Content: "Released"},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := postTags(tt.post, tt.feed, rules); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("postTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, err = tagRules([]config.TagRule{{Tag: "generics", Patterns: []string{"(generic"}}})
+	if err == nil {
+		t.Errorf("tagRules() error = nil, want error for bad pattern")
+	}
+}
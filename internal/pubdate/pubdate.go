@@ -0,0 +1,164 @@
+// Пакет для разбора дат публикации из RSS лент.
+//
+// Ленты указывают даты в самых разных форматах: RFC1123 с числовым
+// смещением или названием часового пояса, RFC3339, с двузначным годом,
+// без секунд, без дня недели и так далее. Пакет перебирает известные
+// форматы и приводит результат к UTC.
+package pubdate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrUnknownFormat = errors.New("unknown date format")
+
+// layouts - форматы дат, встречающиеся в реальных лентах. Порядок
+// важен: более точные форматы проверяются раньше.
+var layouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 Z0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -07:00",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 MST",
+	"Mon, 2 Jan 06 15:04 -0700",
+	"Mon, 2 Jan 06 15:04 MST",
+	"Monday, 2 January 2006 15:04:05 -0700",
+	"Monday, 2 January 2006 15:04:05 MST",
+	"Mon, 2 January 2006 15:04:05 -0700",
+	"Mon, 2 January 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05",
+	"Mon, 2 Jan 2006",
+
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 MST",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04:05 MST",
+	"2 Jan 2006",
+
+	time.UnixDate,
+	time.ANSIC,
+	time.RubyDate,
+	"Jan 2, 2006 15:04:05 MST",
+	"Jan 2, 2006 3:04 PM MST",
+	"January 2, 2006",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02.01.2006",
+}
+
+// zones - смещения часовых поясов, названия которых встречаются в лентах.
+// Стандартная библиотека не знает смещения для названий, не относящихся
+// к локальному часовому поясу, и считает их равными UTC.
+var zones = map[string]int{
+	"MSK":  3 * 3600,
+	"MSD":  4 * 3600,
+	"SAMT": 4 * 3600,
+	"YEKT": 5 * 3600,
+	"OMST": 6 * 3600,
+	"NOVT": 7 * 3600,
+	"KRAT": 7 * 3600,
+	"IRKT": 8 * 3600,
+	"YAKT": 9 * 3600,
+	"VLAT": 10 * 3600,
+	"MAGT": 11 * 3600,
+	"PETT": 12 * 3600,
+	"EET":  2 * 3600,
+	"EEST": 3 * 3600,
+	"CET":  1 * 3600,
+	"CEST": 2 * 3600,
+	"WET":  0,
+	"WEST": 1 * 3600,
+	"BST":  1 * 3600,
+	"IST":  5*3600 + 1800,
+	"JST":  9 * 3600,
+	"KST":  9 * 3600,
+	"HKT":  8 * 3600,
+	"SGT":  8 * 3600,
+	"AEST": 10 * 3600,
+	"AEDT": 11 * 3600,
+	"EST":  -5 * 3600,
+	"EDT":  -4 * 3600,
+	"CST":  -6 * 3600,
+	"CDT":  -5 * 3600,
+	"MST":  -7 * 3600,
+	"MDT":  -6 * 3600,
+	"PST":  -8 * 3600,
+	"PDT":  -7 * 3600,
+}
+
+// Parse разбирает дату публикации в одном из известных форматов и
+// возвращает ее в UTC. Даты без часового пояса считаются датами в UTC.
+func Parse(str string) (time.Time, error) {
+	const operation = "pubdate.Parse"
+
+	s := normalize(str)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("%s: %w: empty date", operation, ErrUnknownFormat)
+	}
+
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		return withZone(t).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%s: %w: %q", operation, ErrUnknownFormat, str)
+}
+
+// normalize убирает лишние пробелы и комментарии в скобках вида
+// "(MSK)" после числового смещения, а также заменяет названия
+// UT и Z на GMT, которое стандартная библиотека понимает корректно.
+func normalize(str string) string {
+	s := strings.Join(strings.Fields(str), " ")
+	if i := strings.IndexByte(s, '('); i > 0 && strings.HasSuffix(s, ")") {
+		s = strings.TrimSpace(s[:i])
+	}
+
+	for _, suffix := range []string{" UT", " Z"} {
+		if strings.HasSuffix(s, suffix) {
+			s = strings.TrimSuffix(s, suffix) + " GMT"
+		}
+	}
+	return s
+}
+
+// withZone исправляет смещение времени, разобранного с названием
+// часового пояса, неизвестным стандартной библиотеке.
+func withZone(t time.Time) time.Time {
+	name, offset := t.Zone()
+	if offset != 0 {
+		return t
+	}
+
+	zone, ok := zones[name]
+	if !ok || zone == 0 {
+		return t
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(),
+		t.Nanosecond(), time.FixedZone(name, zone))
+}
@@ -0,0 +1,68 @@
+// Пакет для разбора дат публикации из RSS лент.
+
+package pubdate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	want := time.Date(2024, time.July, 27, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		date    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "RFC1123", date: "Sat, 27 Jul 2024 09:30:00 GMT", want: want},
+		{name: "RFC1123Z", date: "Sat, 27 Jul 2024 12:30:00 +0300", want: want},
+		{name: "RFC1123_Colon_Offset", date: "Sat, 27 Jul 2024 12:30:00 +03:00", want: want},
+		{name: "RFC1123_MSK", date: "Sat, 27 Jul 2024 12:30:00 MSK", want: want},
+		{name: "RFC1123_PDT", date: "Sat, 27 Jul 2024 02:30:00 PDT", want: want},
+		{name: "RFC1123_One_Digit_Day", date: "Sat, 7 Jul 2024 09:30:00 GMT", want: want.AddDate(0, 0, -20)},
+		{name: "RFC1123_UT", date: "Sat, 27 Jul 2024 09:30:00 UT", want: want},
+		{name: "RFC1123_Comment", date: "Sat, 27 Jul 2024 12:30:00 +0300 (MSK)", want: want},
+		{name: "Without_Seconds", date: "Sat, 27 Jul 2024 12:30 +0300", want: want},
+		{name: "Two_Digit_Year", date: "Sat, 27 Jul 24 09:30:00 GMT", want: want},
+		{name: "Without_Weekday", date: "27 Jul 2024 12:30:00 +0300", want: want},
+		{name: "Full_Month", date: "Saturday, 27 July 2024 09:30:00 GMT", want: want},
+		{name: "Extra_Spaces", date: "  Sat,  27 Jul 2024\n09:30:00 GMT ", want: want},
+		{name: "RFC3339", date: "2024-07-27T12:30:00+03:00", want: want},
+		{name: "RFC3339_Nano", date: "2024-07-27T09:30:00.000Z", want: want},
+		{name: "RFC3339_Without_Zone", date: "2024-07-27T09:30:00", want: want},
+		{name: "RFC3339_Without_Seconds", date: "2024-07-27T12:30+03:00", want: want},
+		{name: "Date_Only", date: "2024-07-27", want: want.Truncate(24 * time.Hour)},
+		{name: "Russian_Numeric", date: "27.07.2024 09:30", want: want},
+		{name: "Empty", date: "", wantErr: true},
+		{name: "Unknown", date: "вчера", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownFormat) {
+					t.Errorf("Parse() error = %v, want %v", err, ErrUnknownFormat)
+				}
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("Parse() location = %v, want UTC", got.Location())
+			}
+		})
+	}
+}
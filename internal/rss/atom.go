@@ -0,0 +1,196 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Пространство имен XML, к которому относится атрибут xml:lang.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// Пространство имен Atom, элементы которого встречаются и в лентах
+// RSS 2.0.
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomEntry - структура одной записи в ленте Atom.
+type atomEntry struct {
+	media
+	Title      atomText       `xml:"title"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Links      []atomLink     `xml:"link"`
+	ID         string         `xml:"id"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// atomPerson - автор записи Atom.
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+// atomCategory - категория записи Atom. Человекочитаемое название
+// передается в необязательном атрибуте label.
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// atomText - текстовая конструкция Atom. В зависимости от атрибута
+// type содержимое может быть простым текстом, экранированным HTML
+// или вложенной XHTML разметкой.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// atomLink - ссылка записи Atom.
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// String возвращает содержимое текстовой конструкции. Для XHTML
+// возвращается разметка внутри элемента, для остальных типов -
+// текст с уже раскрытыми XML сущностями.
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// atomElement обрабатывает очередной элемент ленты Atom 1.0 на уровне
+// корневого элемента feed.
+func atomElement(d *xml.Decoder, se *xml.StartElement, ch *Channel) (*Item, error) {
+	switch se.Name.Local {
+	case "entry":
+		var e atomEntry
+		err := d.DecodeElement(&e, se)
+		if err != nil {
+			return nil, err
+		}
+		item := e.item()
+		return &item, nil
+	case "title":
+		var t atomText
+		err := d.DecodeElement(&t, se)
+		ch.Title = t.String()
+		return nil, err
+	case "subtitle":
+		var t atomText
+		err := d.DecodeElement(&t, se)
+		ch.Description = t.String()
+		return nil, err
+	case "link":
+		var l atomLink
+		err := d.DecodeElement(&l, se)
+		if ch.Link == "" {
+			ch.Link = alternateLink([]atomLink{l})
+		}
+		hubLink(l, ch)
+		return nil, err
+	case "logo":
+		return nil, decodeString(d, se, &ch.Image)
+	case "icon":
+		// Логотип канала предпочтительнее иконки.
+		if ch.Image != "" {
+			return nil, d.Skip()
+		}
+		return nil, decodeString(d, se, &ch.Image)
+	default:
+		return nil, d.Skip()
+	}
+}
+
+// atomLang возвращает язык ленты Atom из атрибута xml:lang корневого
+// элемента.
+func atomLang(root *xml.StartElement) string {
+	for _, a := range root.Attr {
+		if a.Name.Space == xmlNamespace && a.Name.Local == "lang" {
+			return strings.TrimSpace(a.Value)
+		}
+	}
+	return ""
+}
+
+// item преобразует запись Atom в нормализованный пост. Полное
+// содержимое записи имеет приоритет над кратким описанием, дата
+// публикации - над датой обновления.
+func (e atomEntry) item() Item {
+	var i Item
+	i.Title = e.Title.String()
+
+	i.Description = e.Content.String()
+	if i.Description == "" {
+		i.Description = e.Summary.String()
+	}
+
+	i.PubDate = strings.TrimSpace(e.Published)
+	if i.PubDate == "" {
+		i.PubDate = strings.TrimSpace(e.Updated)
+	}
+
+	i.Link = alternateLink(e.Links)
+	i.Image = e.image()
+	i.GUID = strings.TrimSpace(e.ID)
+
+	names := make([]string, 0, len(e.Authors))
+	for _, a := range e.Authors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	i.Author = strings.Join(names, ", ")
+
+	for _, c := range e.Categories {
+		name := strings.TrimSpace(c.Label)
+		if name == "" {
+			name = strings.TrimSpace(c.Term)
+		}
+		if name != "" {
+			i.Categories = append(i.Categories, name)
+		}
+	}
+
+	for _, l := range e.Links {
+		if l.Rel != "enclosure" || l.Href == "" {
+			continue
+		}
+		i.Enclosures = append(i.Enclosures, Enclosure{
+			URL:    strings.TrimSpace(l.Href),
+			Type:   strings.TrimSpace(l.Type),
+			Length: parseLength(l.Length),
+		})
+	}
+	return i
+}
+
+// alternateLink возвращает адрес из ссылки с rel="alternate". По
+// спецификации Atom отсутствующий атрибут rel равен "alternate".
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+// hubLink записывает в канал адреса из ссылок rel="hub" и rel="self".
+// Используется первая ссылка каждого вида.
+func hubLink(l atomLink, ch *Channel) {
+	href := strings.TrimSpace(l.Href)
+	switch {
+	case l.Rel == "hub" && ch.Hub == "":
+		ch.Hub = href
+	case l.Rel == "self" && ch.Self == "":
+		ch.Self = href
+	}
+}
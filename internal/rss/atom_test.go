@@ -0,0 +1,51 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestParse_Atom позволяет проверить преобразование записей ленты
+// Atom в нормализованную структуру Item.
+func TestParse_Atom(t *testing.T) {
+	file, err := os.Open("testAtom.xml")
+	if err != nil {
+		t.Fatalf("Parse() error = cannot open test Atom feed")
+	}
+	defer file.Close()
+
+	feed, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if feed.Format != FormatAtom {
+		t.Errorf("Parse() format = %v, want %v", feed.Format, FormatAtom)
+	}
+
+	want := []Item{
+		{
+			Title:       "Go 1.23 is released",
+			Description: "<p>Today the Go team is happy to release Go 1.23.</p>",
+			PubDate:     "2024-08-13T00:00:00+00:00",
+			Link:        "https://go.dev/blog/go1.23",
+			GUID:        "tag:blog.golang.org,2013:blog.golang.org/go1.23",
+			Author:      "Eli Bendersky, on behalf of the Go team",
+			Categories:  []string{"Release", "go1.23"},
+			Enclosures: []Enclosure{
+				{URL: "https://go.dev/blog/go1.23/gopher.png", Type: "image/png", Length: 1024},
+			},
+		},
+		{
+			Title:       "Range Over Function Types",
+			Description: `<div xmlns="http://www.w3.org/1999/xhtml"><p>A description of range over function types.</p></div>`,
+			PubDate:     "2024-08-20T00:00:00Z",
+			Link:        "https://go.dev/blog/range-functions",
+			GUID:        "tag:blog.golang.org,2013:blog.golang.org/range-functions",
+		},
+	}
+	if !reflect.DeepEqual(feed.Channel.Items, want) {
+		t.Errorf("Parse() items = %+v, want %+v", feed.Channel.Items, want)
+	}
+}
@@ -0,0 +1,41 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"io"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// httpCharset возвращает кодировку из параметра charset переданного
+// значения заголовка Content-Type. Для UTF-8 и ASCII возвращает
+// "utf-8". При отсутствии параметра возвращает пустую строку - в этом
+// случае кодировка определяется по XML прологу документа.
+func httpCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	cs := strings.ToLower(strings.TrimSpace(params["charset"]))
+	switch cs {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return "utf-8"
+	}
+	return cs
+}
+
+// charsetReader возвращает функцию для поля CharsetReader XML декодера.
+// Если тело ответа уже перекодировано в UTF-8 по кодировке из заголовка
+// HTTP, то кодировка из XML пролога игнорируется, чтобы не перекодировать
+// данные повторно.
+func charsetReader(transcoded bool) func(string, io.Reader) (io.Reader, error) {
+	if transcoded {
+		return func(_ string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+	}
+	return charset.NewReaderLabel
+}
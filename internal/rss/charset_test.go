@@ -0,0 +1,86 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"golang.org/x/net/html/charset"
+)
+
+// TestParseContent_Charset позволяет проверить перекодирование лент
+// в кодировках windows-1251 и koi8-r по XML прологу и по параметру
+// charset заголовка Content-Type, в том числе явно указанной UTF-8.
+func TestParseContent_Charset(t *testing.T) {
+	data1251, err := os.ReadFile("testFeed1251.xml")
+	if err != nil {
+		t.Fatalf("ParseContent() error = cannot read test windows-1251 feed")
+	}
+	dataKOI8, err := os.ReadFile("testFeedKOI8.xml")
+	if err != nil {
+		t.Fatalf("ParseContent() error = cannot read test koi8-r feed")
+	}
+	// Документ без объявления кодировки в прологе, кодировка известна
+	// только из заголовка HTTP.
+	dataNoProlog := dataKOI8[bytes.IndexByte(dataKOI8, '\n')+1:]
+	// Документ в UTF-8 с ошибочной кодировкой в прологе.
+	r, err := charset.NewReaderLabel("windows-1251", bytes.NewReader(data1251))
+	if err != nil {
+		t.Fatalf("ParseContent() error = cannot transcode test windows-1251 feed")
+	}
+	dataUTF8, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ParseContent() error = cannot transcode test windows-1251 feed")
+	}
+
+	const want = "Открытие нового моста через Волгу"
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+	}{
+		{
+			name:        "Prolog_Windows_1251",
+			data:        data1251,
+			contentType: "application/rss+xml",
+		},
+		{
+			name:        "Prolog_KOI8_R",
+			data:        dataKOI8,
+			contentType: "",
+		},
+		{
+			name:        "Header_And_Prolog_Windows_1251",
+			data:        data1251,
+			contentType: "text/xml; charset=windows-1251",
+		},
+		{
+			name:        "Header_KOI8_R",
+			data:        dataNoProlog,
+			contentType: "application/xml; charset=KOI8-R",
+		},
+		{
+			name:        "Header_UTF_8_Prolog_Windows_1251",
+			data:        dataUTF8,
+			contentType: "text/xml; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			feed, err := ParseContent(bytes.NewReader(tt.data), tt.contentType)
+			if err != nil {
+				t.Fatalf("ParseContent() error = %v", err)
+			}
+			if got := feed.Channel.Items[0].Title; got != want {
+				t.Errorf("ParseContent() title = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+var ErrLimitExceeded = errors.New("the feed limit is exceeded")
+
+// Названия ограничений декодера.
+const (
+	LimitBodySize = "body size"
+	LimitItems    = "items"
+)
+
+// Limits - ограничения на размер декодируемой ленты. Нулевое значение
+// поля означает отсутствие ограничения.
+type Limits struct {
+	MaxBodySize int64
+	MaxItems    int
+}
+
+// LimitError - ошибка превышения одного из ограничений декодера.
+// Посты, полученные до возникновения ошибки, остаются корректными.
+type LimitError struct {
+	Limit string
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %s > %d", ErrLimitExceeded, e.Limit, e.Max)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// limitReader читает не более max байт из тела ответа. При попытке
+// прочитать больше возвращает LimitError вместо тихого обрезания
+// документа, чтобы декодер не принял обрезанную ленту за полную.
+type limitReader struct {
+	r   io.Reader
+	max int64
+	n   int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n > l.max {
+		return 0, &LimitError{Limit: LimitBodySize, Max: l.max}
+	}
+	// Читаем на один байт больше лимита, чтобы отличить документ
+	// ровно максимального размера от слишком большого.
+	if rest := l.max - l.n + 1; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.max {
+		n -= int(l.n - l.max)
+		return n, &LimitError{Limit: LimitBodySize, Max: l.max}
+	}
+	return n, err
+}
+
+// elementFunc обрабатывает очередной элемент XML ленты. Если элемент
+// является постом, возвращает его, метаданные канала записывает в ch.
+type elementFunc func(d *xml.Decoder, se *xml.StartElement, ch *Channel) (*Item, error)
+
+// Decoder - потоковый декодер ленты. Посты читаются из тела ответа по
+// одному, поэтому в памяти не хранится вся лента целиком.
+type Decoder struct {
+	format  string
+	channel Channel
+	limits  Limits
+	count   int
+
+	xml     *xml.Decoder
+	element elementFunc
+	json    *jsonStream
+
+	// Первый пост читается заранее, чтобы метаданные канала, которые
+	// в лентах предшествуют постам, были доступны сразу после создания
+	// декодера.
+	next *Item
+	err  error
+}
+
+// NewDecoder создает потоковый декодер ленты. Формат ленты определяется
+// по типу содержимого из заголовка Content-Type, а если он не указан -
+// по корневому элементу XML документа или первому символу JSON.
+// Кодировка из заголовка имеет приоритет над XML прологом.
+func NewDecoder(body io.Reader, contentType string, limits Limits) (*Decoder, error) {
+	const operation = "rss.NewDecoder"
+
+	if body == nil {
+		return nil, fmt.Errorf("%s: %w", operation, ErrBodyNil)
+	}
+
+	if limits.MaxBodySize > 0 {
+		body = &limitReader{r: body, max: limits.MaxBodySize}
+	}
+
+	// Кодировка из заголовка HTTP, в том числе явно указанная UTF-8,
+	// имеет приоритет над XML прологом, поэтому тело сразу
+	// перекодируется в UTF-8.
+	cs := httpCharset(contentType)
+	if cs != "" && cs != "utf-8" {
+		var err error
+		body, err = charset.NewReaderLabel(cs, body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", operation, err)
+		}
+	}
+
+	br := bufio.NewReader(body)
+	dec := &Decoder{limits: limits}
+
+	var err error
+	switch {
+	case isJSON(contentType, br):
+		err = dec.initJSON(br)
+	default:
+		err = dec.initXML(br, cs != "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	item, err := dec.read()
+	switch {
+	case err == io.EOF:
+		dec.err = err
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	default:
+		dec.next = &item
+	}
+
+	return dec, nil
+}
+
+// initJSON подготавливает декодер к чтению ленты JSON Feed.
+func (d *Decoder) initJSON(body io.Reader) error {
+	js, err := newJSONStream(body)
+	if err != nil {
+		return err
+	}
+	d.format = FormatJSON
+	d.json = js
+	return nil
+}
+
+// initXML читает корневой элемент XML документа и выбирает
+// обработчик элементов для его формата.
+func (d *Decoder) initXML(body io.Reader, transcoded bool) error {
+	d.xml = xml.NewDecoder(body)
+	d.xml.CharsetReader = charsetReader(transcoded)
+	root, err := rootElement(d.xml)
+	if err != nil {
+		return err
+	}
+
+	switch root.Name.Local {
+	case "rss":
+		d.format, d.element = FormatRSS, rssElement
+	case "feed":
+		d.format, d.element = FormatAtom, atomElement
+		d.channel.Language = atomLang(&root)
+	case "RDF":
+		d.format, d.element = FormatRDF, rdfElement
+	default:
+		return fmt.Errorf("%w: <%s>", ErrUnknownFormat, root.Name.Local)
+	}
+	return nil
+}
+
+// Next возвращает следующий пост ленты. По окончании ленты возвращает
+// io.EOF. После первой ошибки все последующие вызовы возвращают ее же.
+func (d *Decoder) Next() (Item, error) {
+	const operation = "rss.Decoder.Next"
+
+	if d.next != nil {
+		item := *d.next
+		d.next = nil
+		return item, nil
+	}
+	if d.err != nil {
+		return Item{}, d.err
+	}
+
+	item, err := d.read()
+	if err == io.EOF {
+		d.err = err
+		return Item{}, err
+	}
+	if err != nil {
+		d.err = fmt.Errorf("%s: %w", operation, err)
+		return Item{}, d.err
+	}
+	return item, nil
+}
+
+// Channel возвращает метаданные канала. Метаданные, расположенные
+// в документе после постов, становятся доступны по мере чтения ленты.
+func (d *Decoder) Channel() Channel {
+	return d.channel
+}
+
+// Format возвращает формат декодируемой ленты.
+func (d *Decoder) Format() string {
+	return d.format
+}
+
+// read читает следующий пост из документа с учетом ограничения на
+// количество постов.
+func (d *Decoder) read() (Item, error) {
+	var item Item
+	var err error
+	if d.json != nil {
+		item, err = d.json.next(&d.channel)
+	} else {
+		item, err = d.nextXML()
+	}
+	if err != nil {
+		return Item{}, err
+	}
+
+	d.count++
+	if d.limits.MaxItems > 0 && d.count > d.limits.MaxItems {
+		return Item{}, &LimitError{Limit: LimitItems, Max: int64(d.limits.MaxItems)}
+	}
+	return item, nil
+}
+
+// nextXML перебирает элементы XML документа до следующего поста.
+func (d *Decoder) nextXML() (Item, error) {
+	for {
+		tok, err := d.xml.Token()
+		if err != nil {
+			return Item{}, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		item, err := d.element(d.xml, &se, &d.channel)
+		if err != nil {
+			return Item{}, err
+		}
+		if item != nil {
+			return *item, nil
+		}
+	}
+}
+
+// isJSON сообщает, передана ли лента в формате JSON. Явно указанный
+// JSON или XML тип содержимого имеет приоритет, в остальных случаях
+// проверяется первый значащий символ тела без его вычитывания.
+func isJSON(contentType string, br *bufio.Reader) bool {
+	media, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case media == "application/json", strings.HasSuffix(media, "+json"):
+		return true
+	case media == "text/xml", media == "application/xml", strings.HasSuffix(media, "+xml"):
+		return false
+	}
+
+	for i := 1; ; i++ {
+		b, err := br.Peek(i)
+		if err != nil {
+			return false
+		}
+		switch b[i-1] {
+		// Пропускаем пробельные символы и байты метки порядка UTF-8.
+		case ' ', '\t', '\r', '\n', 0xEF, 0xBB, 0xBF:
+			continue
+		case '{':
+			return true
+		default:
+			return false
+		}
+	}
+}
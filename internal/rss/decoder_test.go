@@ -0,0 +1,149 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// TestNewDecoder_Channel позволяет проверить, что метаданные канала
+// доступны сразу после создания декодера, до чтения постов.
+func TestNewDecoder_Channel(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		format string
+		title  string
+	}{
+		{
+			name:   "RSS",
+			file:   "testFeed.xml",
+			format: FormatRSS,
+			title:  "Все статьи подряд / Go / Хабр",
+		},
+		{
+			name:   "JSON",
+			file:   "testFeed.json",
+			format: FormatJSON,
+			title:  "Golang Digest",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("NewDecoder() error = cannot read test feed")
+			}
+
+			dec, err := NewDecoder(bytes.NewReader(data), "", Limits{})
+			if err != nil {
+				t.Fatalf("NewDecoder() error = %v", err)
+			}
+			if got := dec.Format(); got != tt.format {
+				t.Errorf("NewDecoder() format = %q, want %q", got, tt.format)
+			}
+			if got := dec.Channel().Title; got != tt.title {
+				t.Errorf("NewDecoder() title = %q, want %q", got, tt.title)
+			}
+		})
+	}
+}
+
+// TestDecoder_Limits позволяет проверить ограничения на размер тела
+// ответа и количество постов в ленте.
+func TestDecoder_Limits(t *testing.T) {
+	data, err := os.ReadFile("testFeed.xml")
+	if err != nil {
+		t.Fatalf("Next() error = cannot read test XML feed")
+	}
+
+	tests := []struct {
+		name   string
+		limits Limits
+		items  int
+		limit  string
+	}{
+		{
+			name:   "No_Limits",
+			limits: Limits{},
+			items:  2,
+		},
+		{
+			name:   "Exact_Limits",
+			limits: Limits{MaxBodySize: int64(len(data)), MaxItems: 2},
+			items:  2,
+		},
+		{
+			name:   "Max_Items",
+			limits: Limits{MaxItems: 1},
+			items:  1,
+			limit:  LimitItems,
+		},
+		{
+			name:   "Max_Body_Size",
+			limits: Limits{MaxBodySize: int64(len(data)) - 10},
+			items:  2,
+			limit:  LimitBodySize,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dec, err := NewDecoder(bytes.NewReader(data), "", tt.limits)
+			if err != nil {
+				t.Fatalf("NewDecoder() error = %v", err)
+			}
+
+			var items int
+			for {
+				_, err = dec.Next()
+				if err != nil {
+					break
+				}
+				items++
+			}
+
+			if items != tt.items {
+				t.Errorf("Next() items = %d, want %d", items, tt.items)
+			}
+			if tt.limit == "" {
+				if err != io.EOF {
+					t.Errorf("Next() error = %v, want %v", err, io.EOF)
+				}
+				return
+			}
+
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimitExceeded) {
+				t.Fatalf("Next() error = %v, want %v", err, ErrLimitExceeded)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("Next() limit = %q, want %q", limitErr.Limit, tt.limit)
+			}
+		})
+	}
+}
+
+// TestNewDecoder_Body_Size_Limit позволяет проверить ошибку, если
+// ограничение на размер тела превышено еще до первого поста.
+func TestNewDecoder_Body_Size_Limit(t *testing.T) {
+	data, err := os.ReadFile("testFeed.json")
+	if err != nil {
+		t.Fatalf("NewDecoder() error = cannot read test JSON feed")
+	}
+
+	_, err = NewDecoder(bytes.NewReader(data), "application/feed+json", Limits{MaxBodySize: 64})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("NewDecoder() error = %v, want %v", err, ErrLimitExceeded)
+	}
+}
@@ -0,0 +1,265 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Префикс идентификатора версии в лентах JSON Feed.
+const jsonFeedVersion = "https://jsonfeed.org/version/"
+
+// jsonStream - состояние потокового декодирования ленты в формате
+// JSON Feed 1.1. Документ читается по токенам: поля верхнего уровня
+// записываются в метаданные канала, а элементы массива items
+// декодируются по одному.
+type jsonStream struct {
+	dec     *json.Decoder
+	version string
+	inItems bool
+}
+
+// jsonItem - структура одного поста в ленте JSON Feed. Поле author
+// оставлено для совместимости с версией 1.0 формата.
+type jsonItem struct {
+	ID            jsonID           `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonAuthor     `json:"authors"`
+	Author        *jsonAuthor      `json:"author"`
+	Tags          []string         `json:"tags"`
+	Attachments   []jsonAttachment `json:"attachments"`
+	Image         string           `json:"image"`
+}
+
+// jsonAttachment - вложение поста в ленте JSON Feed.
+type jsonAttachment struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size_in_bytes"`
+}
+
+// jsonID - идентификатор поста в ленте JSON Feed. По спецификации это
+// строка, но некоторые ленты передают его числом.
+type jsonID string
+
+// UnmarshalJSON декодирует идентификатор из строки или числа.
+func (id *jsonID) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*id = jsonID(str)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return err
+	}
+	*id = jsonID(num.String())
+	return nil
+}
+
+// jsonHub - адрес хаба для подписки на обновления ленты JSON Feed.
+type jsonHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// jsonAuthor - автор поста в ленте JSON Feed.
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// newJSONStream читает начало JSON документа и проверяет, что
+// корнем документа является объект.
+func newJSONStream(body io.Reader) (*jsonStream, error) {
+	dec := json.NewDecoder(body)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("%w: json root is not an object", ErrUnknownFormat)
+	}
+	return &jsonStream{dec: dec}, nil
+}
+
+// next возвращает следующий пост ленты, попутно заполняя метаданные
+// канала. Версия формата проверяется перед первым постом, поэтому
+// поле version должно предшествовать массиву items, как в примерах
+// спецификации. По окончании документа возвращает io.EOF.
+func (j *jsonStream) next(ch *Channel) (Item, error) {
+	for {
+		if j.inItems {
+			if j.dec.More() {
+				var v jsonItem
+				err := j.dec.Decode(&v)
+				if err != nil {
+					return Item{}, err
+				}
+				return v.item(), nil
+			}
+			// Закрывающая скобка массива items.
+			_, err := j.dec.Token()
+			if err != nil {
+				return Item{}, err
+			}
+			j.inItems = false
+			continue
+		}
+
+		if !j.dec.More() {
+			// Закрывающая скобка корневого объекта.
+			_, err := j.dec.Token()
+			if err != nil {
+				return Item{}, err
+			}
+			if err := j.checkVersion(); err != nil {
+				return Item{}, err
+			}
+			return Item{}, io.EOF
+		}
+
+		tok, err := j.dec.Token()
+		if err != nil {
+			return Item{}, err
+		}
+		key, _ := tok.(string)
+
+		var dst *string
+		switch key {
+		case "items":
+			if err := j.checkVersion(); err != nil {
+				return Item{}, err
+			}
+			tok, err := j.dec.Token()
+			if err != nil {
+				return Item{}, err
+			}
+			if tok != json.Delim('[') {
+				return Item{}, fmt.Errorf("%w: json items is not an array", ErrUnknownFormat)
+			}
+			j.inItems = true
+			continue
+		case "version":
+			dst = &j.version
+		case "title":
+			dst = &ch.Title
+		case "home_page_url":
+			dst = &ch.Link
+		case "feed_url":
+			dst = &ch.Self
+		case "hubs":
+			var hubs []jsonHub
+			err := j.dec.Decode(&hubs)
+			if err != nil {
+				return Item{}, err
+			}
+			ch.Hub = websubHub(hubs)
+			continue
+		case "description":
+			dst = &ch.Description
+		case "language":
+			dst = &ch.Language
+		case "icon":
+			dst = &ch.Image
+		case "favicon":
+			// Иконка канала предпочтительнее favicon.
+			if ch.Image == "" {
+				dst = &ch.Image
+			}
+		}
+
+		if dst == nil {
+			var skip json.RawMessage
+			err = j.dec.Decode(&skip)
+		} else {
+			err = j.dec.Decode(dst)
+			*dst = strings.TrimSpace(*dst)
+		}
+		if err != nil {
+			return Item{}, err
+		}
+	}
+}
+
+// checkVersion проверяет, что документ является лентой JSON Feed.
+func (j *jsonStream) checkVersion() error {
+	if !strings.HasPrefix(j.version, jsonFeedVersion) {
+		return fmt.Errorf("%w: json version %q", ErrUnknownFormat, j.version)
+	}
+	return nil
+}
+
+// item преобразует пост JSON Feed в нормализованный пост. HTML
+// содержимое имеет приоритет над текстовым и кратким описанием.
+func (j jsonItem) item() Item {
+	var i Item
+	i.Title = strings.TrimSpace(j.Title)
+
+	switch {
+	case j.ContentHTML != "":
+		i.Description = j.ContentHTML
+	case j.ContentText != "":
+		i.Description = j.ContentText
+	default:
+		i.Description = j.Summary
+	}
+	i.Description = strings.TrimSpace(i.Description)
+
+	i.PubDate = j.DatePublished
+	if i.PubDate == "" {
+		i.PubDate = j.DateModified
+	}
+
+	i.Link = j.URL
+	if i.Link == "" {
+		i.Link = j.ExternalURL
+	}
+
+	authors := j.Authors
+	if len(authors) == 0 && j.Author != nil {
+		authors = []jsonAuthor{*j.Author}
+	}
+	names := make([]string, 0, len(authors))
+	for _, a := range authors {
+		if a.Name != "" {
+			names = append(names, a.Name)
+		}
+	}
+	i.Author = strings.Join(names, ", ")
+
+	i.GUID = strings.TrimSpace(string(j.ID))
+	i.Image = strings.TrimSpace(j.Image)
+	i.Categories = trimAll(j.Tags)
+
+	for _, a := range j.Attachments {
+		if a.URL == "" {
+			continue
+		}
+		i.Enclosures = append(i.Enclosures, Enclosure{
+			URL:    a.URL,
+			Type:   a.MimeType,
+			Length: a.Size,
+		})
+	}
+	return i
+}
+
+// websubHub возвращает адрес первого хаба WebSub из списка хабов ленты.
+func websubHub(hubs []jsonHub) string {
+	for _, h := range hubs {
+		if strings.EqualFold(h.Type, "websub") {
+			return strings.TrimSpace(h.URL)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseContent_JSON позволяет проверить выбор формата JSON Feed по
+// заголовку Content-Type и по содержимому, а также преобразование
+// постов в нормализованную структуру Item.
+func TestParseContent_JSON(t *testing.T) {
+	data, err := os.ReadFile("testFeed.json")
+	if err != nil {
+		t.Fatalf("ParseContent() error = cannot read test JSON feed")
+	}
+
+	want := []Item{
+		{
+			Title:       "Iterators in Go 1.23",
+			Description: "<p>Range over functions is finally here.</p>",
+			PubDate:     "2024-08-14T10:00:00+03:00",
+			Link:        "https://example.org/2024/08/iterators",
+			GUID:        "https://example.org/2024/08/iterators",
+			Author:      "Ivan Petrov, Anna Smirnova",
+			Categories:  []string{"go", "iterators"},
+			Enclosures: []Enclosure{
+				{URL: "https://example.org/2024/08/iterators.mp3", Type: "audio/mpeg", Length: 2048},
+			},
+		},
+		{
+			Description: "Release notes for Go 1.23.",
+			PubDate:     "2024-08-15T00:00:00Z",
+			Link:        "https://go.dev/doc/go1.23",
+			GUID:        "2",
+			Author:      "The Go Team",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{name: "Content_Type_Feed_JSON", contentType: "application/feed+json; charset=utf-8"},
+		{name: "Content_Type_JSON", contentType: "application/json"},
+		{name: "Sniffing_Text_Plain", contentType: "text/plain"},
+		{name: "Sniffing_No_Type", contentType: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			feed, err := ParseContent(bytes.NewReader(data), tt.contentType)
+			if err != nil {
+				t.Fatalf("ParseContent() error = %v", err)
+			}
+			if feed.Format != FormatJSON {
+				t.Errorf("ParseContent() format = %v, want %v", feed.Format, FormatJSON)
+			}
+			if !reflect.DeepEqual(feed.Channel.Items, want) {
+				t.Errorf("ParseContent() items = %+v, want %+v", feed.Channel.Items, want)
+			}
+		})
+	}
+}
+
+// TestParseContent_JSON_Version позволяет проверить отказ от JSON
+// документов, не являющихся лентой JSON Feed.
+func TestParseContent_JSON_Version(t *testing.T) {
+	_, err := ParseContent(strings.NewReader(`{"items": [{"title": "Post"}]}`), "")
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ParseContent() error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
@@ -0,0 +1,64 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import "strings"
+
+// media - элементы поста из пространства имен Media RSS, которые
+// встречаются в лентах RSS 2.0, RDF и Atom. Элементы media:thumbnail
+// и media:content могут быть вложены в media:group.
+type media struct {
+	Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Contents   []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	Groups     []mediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// mediaGroup - группа вариантов одного медиа объекта.
+type mediaGroup struct {
+	Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Contents   []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+// mediaThumbnail - миниатюра поста.
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+// mediaContent - медиа объект поста. Тип объекта задается атрибутом
+// medium или MIME типом в атрибуте type.
+type mediaContent struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+// image возвращает адрес изображения поста: первую миниатюру или,
+// если миниатюр нет, первый объект-изображение. Элементы верхнего
+// уровня имеют приоритет над вложенными в media:group.
+func (m media) image() string {
+	if url := mediaImage(m.Thumbnails, m.Contents); url != "" {
+		return url
+	}
+	for _, g := range m.Groups {
+		if url := mediaImage(g.Thumbnails, g.Contents); url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+// mediaImage возвращает адрес первой миниатюры или первого
+// объекта-изображения из переданных.
+func mediaImage(thumbs []mediaThumbnail, contents []mediaContent) string {
+	for _, t := range thumbs {
+		if url := strings.TrimSpace(t.URL); url != "" {
+			return url
+		}
+	}
+	for _, c := range contents {
+		url := strings.TrimSpace(c.URL)
+		if url != "" && (c.Medium == "image" || strings.HasPrefix(c.Type, "image/")) {
+			return url
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParse_Media позволяет проверить извлечение изображения поста
+// из элементов Media RSS.
+func TestParse_Media(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		feed string
+		want []string
+	}{
+		{
+			name: "RSS",
+			feed: `<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/"><channel><title>Media</title>
+				<item><title>Миниатюра</title><media:content url="https://a.com/video.mp4" type="video/mp4"/><media:thumbnail url=" https://a.com/thumb.jpg "/></item>
+				<item><title>Изображение</title><media:content url="https://a.com/video.mp4" medium="video"/><media:content url="https://a.com/photo.jpg" medium="image"/></item>
+				<item><title>Группа</title><media:group><media:content url="https://a.com/large.png" type="image/png"/><media:content url="https://a.com/small.png" type="image/png"/></media:group></item>
+				<item><title>Без изображения</title><description>Текст</description></item>
+			</channel></rss>`,
+			want: []string{"https://a.com/thumb.jpg", "https://a.com/photo.jpg", "https://a.com/large.png", ""},
+		},
+		{
+			name: "Atom",
+			feed: `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/"><title>Media</title>
+				<entry><title>Видео</title><content type="html">&lt;p&gt;Текст&lt;/p&gt;</content><media:group><media:thumbnail url="https://youtube.com/hq.jpg"/></media:group></entry>
+			</feed>`,
+			want: []string{"https://youtube.com/hq.jpg"},
+		},
+		{
+			name: "RDF",
+			feed: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:media="http://search.yahoo.com/mrss/">
+				<channel rdf:about="https://a.com/"><title>Media</title></channel>
+				<item rdf:about="https://a.com/1"><title>Пост</title><media:thumbnail url="https://a.com/1.jpg"/></item>
+			</rdf:RDF>`,
+			want: []string{"https://a.com/1.jpg"},
+		},
+		{
+			name: "JSON",
+			feed: `{"version": "https://jsonfeed.org/version/1.1", "title": "Media", "items": [{"id": "1", "image": "https://a.com/cover.webp"}]}`,
+			want: []string{"https://a.com/cover.webp"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			feed, err := Parse(strings.NewReader(tt.feed))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(feed.Channel.Items) != len(tt.want) {
+				t.Fatalf("Parse() items = %d, want %d", len(feed.Channel.Items), len(tt.want))
+			}
+			for i, item := range feed.Channel.Items {
+				if item.Image != tt.want[i] {
+					t.Errorf("Parse() image = %q, want %q", item.Image, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// rdfChannel - структура канала ленты RSS 1.0 (RDF). В отличие от
+// RSS 2.0 элементы item и image находятся на одном уровне с элементом
+// channel, а не внутри него.
+type rdfChannel struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Language    string `xml:"http://purl.org/dc/elements/1.1/ language"`
+}
+
+// rdfItem - структура одного поста в ленте RSS 1.0. Дата публикации,
+// автор и темы передаются в элементах из пространства имен Dublin Core,
+// уникальным идентификатором поста служит атрибут rdf:about.
+type rdfItem struct {
+	media
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Description string   `xml:"description"`
+	Link        string   `xml:"link"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+// rdfElement обрабатывает очередной элемент ленты RSS 1.0 на уровне
+// корневого элемента rdf:RDF.
+func rdfElement(d *xml.Decoder, se *xml.StartElement, ch *Channel) (*Item, error) {
+	switch se.Name.Local {
+	case "item":
+		var v rdfItem
+		err := d.DecodeElement(&v, se)
+		if err != nil {
+			return nil, err
+		}
+		item := v.item()
+		return &item, nil
+	case "channel":
+		var c rdfChannel
+		err := d.DecodeElement(&c, se)
+		ch.Title = strings.TrimSpace(c.Title)
+		ch.Link = strings.TrimSpace(c.Link)
+		ch.Description = strings.TrimSpace(c.Description)
+		ch.Language = strings.TrimSpace(c.Language)
+		return nil, err
+	case "image":
+		var img rssImage
+		err := d.DecodeElement(&img, se)
+		ch.Image = strings.TrimSpace(img.URL)
+		return nil, err
+	default:
+		return nil, d.Skip()
+	}
+}
+
+// item преобразует пост RSS 1.0 в нормализованный пост.
+func (r rdfItem) item() Item {
+	return Item{
+		Title:       strings.TrimSpace(r.Title),
+		Description: strings.TrimSpace(r.Description),
+		PubDate:     strings.TrimSpace(r.Date),
+		Link:        strings.TrimSpace(r.Link),
+		GUID:        strings.TrimSpace(r.About),
+		Author:      strings.TrimSpace(r.Creator),
+		Categories:  trimAll(r.Subjects),
+		Image:       r.image(),
+	}
+}
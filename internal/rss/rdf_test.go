@@ -0,0 +1,48 @@
+// Пакет для декодирования RSS потока.
+package rss
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestParse_RDF позволяет проверить декодирование ленты RSS 1.0, в которой
+// элементы item находятся вне элемента channel.
+func TestParse_RDF(t *testing.T) {
+	file, err := os.Open("testRDF.xml")
+	if err != nil {
+		t.Fatalf("Parse() error = cannot open test RDF feed")
+	}
+	defer file.Close()
+
+	feed, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if feed.Format != FormatRDF {
+		t.Errorf("Parse() format = %v, want %v", feed.Format, FormatRDF)
+	}
+
+	want := []Item{
+		{
+			Title:       "Выпуск языка программирования Go 1.23",
+			Description: "Представлен выпуск языка программирования Go 1.23.",
+			PubDate:     "2024-08-14T10:21:00+03:00",
+			Link:        "https://www.opennet.ru/opennews/art.shtml?num=61600",
+			GUID:        "https://www.opennet.ru/opennews/art.shtml?num=61600",
+			Author:      "opennet",
+			Categories:  []string{"go", "golang"},
+		},
+		{
+			Title:       "Релиз ядра Linux 6.11",
+			Description: "<p>Линус Торвальдс представил релиз ядра Linux 6.11.</p>",
+			PubDate:     "2024-09-15T22:05:00Z",
+			Link:        "https://www.opennet.ru/opennews/art.shtml?num=61601",
+			GUID:        "https://www.opennet.ru/opennews/art.shtml?num=61601",
+		},
+	}
+	if !reflect.DeepEqual(feed.Channel.Items, want) {
+		t.Errorf("Parse() items = %+v, want %+v", feed.Channel.Items, want)
+	}
+}
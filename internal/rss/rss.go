@@ -6,43 +6,130 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
 var (
-	ErrBodyNil   = errors.New("the response body is nil")
-	ErrEmptyFeed = errors.New("the feed is empty")
+	ErrBodyNil       = errors.New("the response body is nil")
+	ErrEmptyFeed     = errors.New("the feed is empty")
+	ErrUnknownFormat = errors.New("unknown feed format")
 )
 
-// Feed - структура вывода RSS потока.
+// Форматы поддерживаемых лент.
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatRDF  = "rdf"
+	FormatJSON = "json"
+)
+
+// Feed - нормализованная структура ленты, не зависящая от ее формата.
 type Feed struct {
-	XMLName xml.Name `xml:"rss"`
-	Channel struct {
-		Items []Item `xml:"item"`
-	} `xml:"channel"`
+	Format  string
+	Channel Channel
+}
+
+// Channel - структура канала ленты с метаданными издания и списком
+// постов.
+type Channel struct {
+	Title       string
+	Link        string
+	Description string
+	Language    string
+	Image       string
+	// Hub и Self - адреса хаба WebSub и самой ленты из ссылок
+	// rel="hub" и rel="self", если лента их объявляет.
+	Hub   string
+	Self  string
+	Items []Item
 }
 
-// Item - структура одного поста в RSS потоке.
+// Item - нормализованная структура одного поста в ленте. Image - адрес
+// изображения поста из элементов Media RSS или поля image JSON Feed.
 type Item struct {
-	Title       string `xml:"title"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
-	Link        string `xml:"link"`
+	Title       string
+	Description string
+	PubDate     string
+	Link        string
+	GUID        string
+	Author      string
+	Categories  []string
+	Enclosures  []Enclosure
+	Image       string
+}
+
+// Enclosure - вложение поста (изображение, аудио или видео файл).
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// rssImage - изображение канала RSS.
+type rssImage struct {
+	URL string `xml:"url"`
+}
+
+// rssItem - структура одного поста в ленте RSS 2.0. Автор поста
+// указывается в элементе author или dc:creator из пространства имен
+// Dublin Core, изображение - в элементах Media RSS.
+type rssItem struct {
+	media
+
+	Title       string         `xml:"title"`
+	Description string         `xml:"description"`
+	PubDate     string         `xml:"pubDate"`
+	Link        string         `xml:"link"`
+	GUID        string         `xml:"guid"`
+	Author      string         `xml:"author"`
+	Creator     string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string       `xml:"category"`
+	Enclosures  []rssEnclosure `xml:"enclosure"`
 }
 
-// Parse десериализует RSS поток в структуру Feed.
+// rssEnclosure - вложение поста в ленте RSS 2.0. Длина хранится
+// строкой, так как в реальных лентах атрибут length часто пустой
+// или некорректный.
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// Parse десериализует ленту в структуру Feed, определяя ее формат
+// по содержимому.
 func Parse(body io.Reader) (Feed, error) {
+	return ParseContent(body, "")
+}
+
+// ParseContent десериализует всю ленту в структуру Feed. Формат ленты
+// определяется так же, как в NewDecoder. Для больших лент следует
+// использовать Decoder напрямую, чтобы не держать все посты в памяти.
+func ParseContent(body io.Reader, contentType string) (Feed, error) {
 	const operation = "rss.Parse"
 	var feed Feed
 
-	if body == nil {
-		return feed, fmt.Errorf("%s: %w", operation, ErrBodyNil)
-	}
-
-	d := xml.NewDecoder(body)
-	err := d.Decode(&feed)
+	dec, err := NewDecoder(body, contentType, Limits{})
 	if err != nil {
 		return feed, fmt.Errorf("%s: %w", operation, err)
 	}
+	feed.Format = dec.Format()
+
+	var items []Item
+	for {
+		item, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return feed, fmt.Errorf("%s: %w", operation, err)
+		}
+		items = append(items, item)
+	}
+
+	feed.Channel = dec.Channel()
+	feed.Channel.Items = items
 
 	if len(feed.Channel.Items) == 0 {
 		return feed, fmt.Errorf("%s: %w", operation, ErrEmptyFeed)
@@ -50,3 +137,121 @@ func Parse(body io.Reader) (Feed, error) {
 
 	return feed, nil
 }
+
+// rootElement пропускает пролог документа, комментарии и инструкции
+// обработки и возвращает первый открывающий элемент.
+func rootElement(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se, nil
+		}
+	}
+}
+
+// rssElement обрабатывает очередной элемент ленты RSS 2.0. Элемент
+// channel не пропускается, чтобы декодер перешел к его содержимому.
+// Из элементов других пространств имен на уровне канала используются
+// только ссылки atom:link на хаб WebSub и саму ленту.
+func rssElement(d *xml.Decoder, se *xml.StartElement, ch *Channel) (*Item, error) {
+	if se.Name.Space == atomNamespace && se.Name.Local == "link" {
+		var l atomLink
+		err := d.DecodeElement(&l, se)
+		hubLink(l, ch)
+		return nil, err
+	}
+	if se.Name.Space != "" {
+		return nil, d.Skip()
+	}
+
+	switch se.Name.Local {
+	case "channel":
+		return nil, nil
+	case "item":
+		var v rssItem
+		err := d.DecodeElement(&v, se)
+		if err != nil {
+			return nil, err
+		}
+		item := v.item()
+		return &item, nil
+	case "title":
+		return nil, decodeString(d, se, &ch.Title)
+	case "link":
+		return nil, decodeString(d, se, &ch.Link)
+	case "description":
+		return nil, decodeString(d, se, &ch.Description)
+	case "language":
+		return nil, decodeString(d, se, &ch.Language)
+	case "image":
+		var img rssImage
+		err := d.DecodeElement(&img, se)
+		ch.Image = strings.TrimSpace(img.URL)
+		return nil, err
+	default:
+		return nil, d.Skip()
+	}
+}
+
+// decodeString декодирует текстовое содержимое элемента в строку,
+// убирая пробельные символы по краям.
+func decodeString(d *xml.Decoder, se *xml.StartElement, dst *string) error {
+	var s string
+	err := d.DecodeElement(&s, se)
+	*dst = strings.TrimSpace(s)
+	return err
+}
+
+// item преобразует пост RSS 2.0 в нормализованный пост.
+func (r rssItem) item() Item {
+	i := Item{
+		Title:       r.Title,
+		Description: r.Description,
+		PubDate:     r.PubDate,
+		Link:        r.Link,
+		GUID:        strings.TrimSpace(r.GUID),
+		Author:      strings.TrimSpace(r.Creator),
+		Categories:  trimAll(r.Categories),
+		Image:       r.image(),
+	}
+	if i.Author == "" {
+		i.Author = strings.TrimSpace(r.Author)
+	}
+	for _, e := range r.Enclosures {
+		if e.URL == "" {
+			continue
+		}
+		i.Enclosures = append(i.Enclosures, Enclosure{
+			URL:    strings.TrimSpace(e.URL),
+			Type:   strings.TrimSpace(e.Type),
+			Length: parseLength(e.Length),
+		})
+	}
+	return i
+}
+
+// trimAll убирает пробельные символы по краям строк и пропускает
+// пустые строки. Для пустого результата возвращает nil.
+func trimAll(list []string) []string {
+	var res []string
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// parseLength преобразует длину вложения в число. Некорректные
+// значения считаются неизвестной длиной.
+func parseLength(str string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
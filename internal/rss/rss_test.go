@@ -7,6 +7,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -38,6 +39,11 @@ var (
 			</channel>
 		</rss>
 	`
+	testDataUnknown = `
+		<html>
+			<head><title>Habr</title></head>
+		</html>
+	`
 )
 
 // TestParse позволяет проверить корректность десериализации RSS фрагмента,
@@ -80,6 +86,13 @@ func TestParse(t *testing.T) {
 			wantErr: true,
 			gotErr:  errDataIncorrect,
 		},
+		{
+			name:    "Parse_Unknown_Format",
+			rss:     strings.NewReader(testDataUnknown),
+			count:   0,
+			wantErr: true,
+			gotErr:  ErrUnknownFormat,
+		},
 		{
 			name:    "Parse_Empty_Body",
 			rss:     strings.NewReader(""),
@@ -131,3 +144,150 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+// TestParse_ItemFields позволяет проверить декодирование идентификатора,
+// автора, категорий и вложений постов ленты RSS 2.0. Категории
+// и вложения есть только в ленте testFeedFields.xml.
+func TestParse_ItemFields(t *testing.T) {
+	file, err := os.Open("testFeedFields.xml")
+	if err != nil {
+		t.Fatalf("Parse() error = cannot open test XML feed")
+	}
+	defer file.Close()
+
+	feed, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		item       Item
+		guid       string
+		author     string
+		categories []string
+		enclosures []Enclosure
+	}{
+		{
+			name:       "Item_With_Enclosure",
+			item:       feed.Channel.Items[0],
+			guid:       "https://habr.com/ru/articles/831252/",
+			author:     "kodIIm",
+			categories: []string{"Блог компании ЦПМ", "Карьера в IT-индустрии", "Go"},
+			enclosures: []Enclosure{
+				{
+					URL:    "https://habrastorage.org/getpro/habr/upload_files/0ea/8be/7bc/0ea8be7bcdd15e01a22aa60ade414a24.jpg",
+					Type:   "image/jpeg",
+					Length: 48213,
+				},
+			},
+		},
+		{
+			name:       "Item_Without_Enclosure",
+			item:       feed.Channel.Items[1],
+			guid:       "https://habr.com/ru/companies/karuna/articles/830346/",
+			author:     "varanio (Karuna)",
+			categories: []string{"Блог компании Каруна", "Go"},
+			enclosures: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.item.GUID != tt.guid {
+				t.Errorf("Parse() guid = %v, want %v", tt.item.GUID, tt.guid)
+			}
+			if tt.item.Author != tt.author {
+				t.Errorf("Parse() author = %v, want %v", tt.item.Author, tt.author)
+			}
+			if !reflect.DeepEqual(tt.item.Categories, tt.categories) {
+				t.Errorf("Parse() categories = %v, want %v", tt.item.Categories, tt.categories)
+			}
+			if !reflect.DeepEqual(tt.item.Enclosures, tt.enclosures) {
+				t.Errorf("Parse() enclosures = %v, want %v", tt.item.Enclosures, tt.enclosures)
+			}
+		})
+	}
+}
+
+// TestParse_Channel позволяет проверить декодирование метаданных канала
+// для каждого поддерживаемого формата ленты.
+func TestParse_Channel(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want Channel
+	}{
+		{
+			name: "RSS",
+			file: "testFeedFields.xml",
+			want: Channel{
+				Title:       "Все статьи подряд / Go / Хабр",
+				Link:        "https://habr.com/ru/hubs/go/articles/",
+				Description: "Go – компилируемый, многопоточный язык программирования",
+				Language:    "ru",
+				Image:       "https://habrastorage.org/webt/ym/el/wk/ymelwk3zy1gawz4nkejl_-ammtc.png",
+				Self:        "https://habr.com/ru/rss/hub/go/all/?fl=ru",
+			},
+		},
+		{
+			name: "Atom",
+			file: "testAtom.xml",
+			want: Channel{
+				Title:       "The Go Blog",
+				Link:        "https://go.dev/blog/",
+				Description: "News and articles about the Go programming language",
+				Language:    "en",
+				Image:       "https://go.dev/images/go-logo-blue.svg",
+				Hub:         "https://pubsubhubbub.appspot.com/",
+				Self:        "https://go.dev/blog/feed.atom",
+			},
+		},
+		{
+			name: "RDF",
+			file: "testRDF.xml",
+			want: Channel{
+				Title:       "OpenNews.opennet.ru: Основная лента",
+				Link:        "https://www.opennet.ru/opennews/",
+				Description: "Новости открытого ПО",
+				Language:    "ru",
+				Image:       "https://www.opennet.ru/opennet.gif",
+			},
+		},
+		{
+			name: "JSON",
+			file: "testFeed.json",
+			want: Channel{
+				Title:       "Golang Digest",
+				Link:        "https://example.org/",
+				Description: "Weekly news about Go",
+				Language:    "en",
+				Image:       "https://example.org/favicon.ico",
+				Hub:         "https://websub.example.org/",
+				Self:        "https://example.org/feed.json",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("Parse() error = cannot read %s", tt.file)
+			}
+
+			feed, err := Parse(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			got := feed.Channel
+			got.Items = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() channel = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+// Пакет для очистки HTML содержания постов по списку разрешенных
+// тегов и атрибутов.
+package sanitize
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowed - разрешенные теги и их разрешенные атрибуты. Остальные теги
+// удаляются с сохранением их текста.
+var allowed = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.B:          nil,
+	atom.Blockquote: {"cite"},
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Kbd:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         {"start"},
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite"},
+	atom.S:          nil,
+	atom.Samp:       nil,
+	atom.Small:      nil,
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Time:       {"datetime"},
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+	atom.Var:        nil,
+}
+
+// dropped - теги, которые удаляются вместе с содержимым.
+var dropped = map[atom.Atom]bool{
+	atom.Applet:   true,
+	atom.Audio:    true,
+	atom.Base:     true,
+	atom.Button:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Head:     true,
+	atom.Iframe:   true,
+	atom.Link:     true,
+	atom.Math:     true,
+	atom.Meta:     true,
+	atom.Noscript: true,
+	atom.Object:   true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Textarea: true,
+	atom.Title:    true,
+	atom.Video:    true,
+}
+
+// void - теги без закрывающего тега.
+var void = map[atom.Atom]bool{
+	atom.Base:  true,
+	atom.Br:    true,
+	atom.Embed: true,
+	atom.Hr:    true,
+	atom.Img:   true,
+	atom.Link:  true,
+	atom.Meta:  true,
+}
+
+// urlAttrs - атрибуты, содержащие ссылки.
+var urlAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+// HTML возвращает очищенный HTML фрагмент. Сохраняются только
+// разрешенные теги и атрибуты, скрипты, фреймы, стили и обработчики
+// событий удаляются, незакрытые теги закрываются. Ссылки в атрибутах
+// преобразуются функцией link, например для разрешения относительных
+// ссылок, и удаляются, если их схема отличается от http, https
+// и mailto. Ссылкам добавляется rel="nofollow noopener noreferrer".
+// Если во фрагменте нет ни текста, ни изображений, возвращает пустую
+// строку.
+func HTML(raw string, link func(string) string) string {
+	if link == nil {
+		link = func(s string) string { return s }
+	}
+
+	var b strings.Builder
+	var open []atom.Atom
+	// skip - тег, удаляемый вместе с содержимым, и глубина его
+	// вложенности.
+	var skip atom.Atom
+	var depth int
+	var content bool
+
+	z := html.NewTokenizer(strings.NewReader(raw))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return ""
+			}
+			break
+		}
+		tok := z.Token()
+
+		if depth > 0 {
+			switch {
+			case tok.DataAtom != skip:
+			case tt == html.StartTagToken:
+				depth++
+			case tt == html.EndTagToken:
+				depth--
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			if strings.TrimSpace(tok.Data) != "" {
+				content = true
+			}
+			b.WriteString(html.EscapeString(tok.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if dropped[tok.DataAtom] {
+				if tt == html.StartTagToken && !void[tok.DataAtom] {
+					skip, depth = tok.DataAtom, 1
+				}
+				continue
+			}
+			attrs, ok := allowed[tok.DataAtom]
+			if !ok {
+				continue
+			}
+			if tok.DataAtom == atom.Img {
+				content = true
+			}
+			writeTag(&b, tok, attrs, link)
+			if !void[tok.DataAtom] {
+				open = append(open, tok.DataAtom)
+			}
+
+		case html.EndTagToken:
+			// Закрываем тег и все незакрытые внутри него, лишние
+			// закрывающие теги пропускаем.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.DataAtom {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j].String() + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i].String() + ">")
+	}
+
+	if !content {
+		return ""
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// writeTag записывает открывающий тег с разрешенными атрибутами attrs.
+func writeTag(b *strings.Builder, tok html.Token, attrs []string, link func(string) string) {
+	b.WriteString("<" + tok.DataAtom.String())
+	for _, name := range attrs {
+		val, ok := attr(tok, name)
+		if !ok {
+			continue
+		}
+		if urlAttrs[name] {
+			val = safeURL(link(strings.TrimSpace(val)), name == "href")
+			if val == "" {
+				continue
+			}
+		}
+		b.WriteString(" " + name + `="` + html.EscapeString(val) + `"`)
+	}
+	if tok.DataAtom == atom.A {
+		b.WriteString(` rel="nofollow noopener noreferrer"`)
+	}
+	b.WriteString(">")
+}
+
+// attr возвращает значение атрибута тега.
+func attr(tok html.Token, name string) (string, bool) {
+	for _, a := range tok.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, name) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// safeURL возвращает ссылку, если ее схема разрешена, иначе пустую
+// строку. Схема mailto разрешена только для ссылок href.
+func safeURL(s string, href bool) string {
+	u, err := url.Parse(s)
+	if err != nil || s == "" {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return s
+	case "mailto":
+		if href {
+			return s
+		}
+	}
+	return ""
+}
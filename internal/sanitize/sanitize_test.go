@@ -0,0 +1,92 @@
+// Пакет для очистки HTML содержания постов по списку разрешенных
+// тегов и атрибутов.
+package sanitize
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	t.Parallel()
+
+	// resolve разрешает ссылки относительно адреса статьи.
+	base, _ := url.Parse("https://go.dev/blog/post")
+	resolve := func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return s
+		}
+		return base.ResolveReference(u).String()
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		link func(string) string
+		want string
+	}{
+		{
+			name: "Structure",
+			raw:  "<h2>Каналы</h2><p>Пример:</p><pre><code>ch := make(chan int)\nch &lt;- 1</code></pre><ul><li>один</li><li>два</li></ul>",
+			want: "<h2>Каналы</h2><p>Пример:</p><pre><code>ch := make(chan int)\nch &lt;- 1</code></pre><ul><li>один</li><li>два</li></ul>",
+		},
+		{
+			name: "Scripts_And_Handlers",
+			raw:  `<p onclick="alert(1)" class="x" style="color:red">Текст<script>alert(1)</script></p><iframe src="https://evil.com"></iframe><style>p{}</style>`,
+			want: "<p>Текст</p>",
+		},
+		{
+			name: "Links",
+			raw:  `<a href="/blog/go1.23" target="_blank">Go 1.23</a> <a href="javascript:alert(1)">x</a> <img src="img/gopher.png" alt="gopher" onerror="alert(1)">`,
+			link: resolve,
+			want: `<a href="https://go.dev/blog/go1.23" rel="nofollow noopener noreferrer">Go 1.23</a> <a rel="nofollow noopener noreferrer">x</a> <img src="https://go.dev/blog/img/gopher.png" alt="gopher">`,
+		},
+		{
+			name: "Relative_Without_Link",
+			raw:  `<img src="gopher.png"><a href="mailto:news@example.com">почта</a>`,
+			want: `<img><a href="mailto:news@example.com" rel="nofollow noopener noreferrer">почта</a>`,
+		},
+		{
+			name: "Unknown_Tags_Unwrapped",
+			raw:  `<section><font color="red">Текст</font> <custom-tag>статьи</custom-tag></section>`,
+			want: "Текст статьи",
+		},
+		{
+			name: "Unbalanced",
+			raw:  "<p><b>жирный<i>курсив</p></b></div>остаток<ul><li>пункт",
+			want: "<p><b>жирный<i>курсив</i></b></p>остаток<ul><li>пункт</li></ul>",
+		},
+		{
+			name: "Nested_Dropped",
+			raw:  "<svg><g><svg><text>a</text></svg></g></svg><p>после</p><meta charset=utf-8><p>мета</p>",
+			want: "<p>после</p><p>мета</p>",
+		},
+		{
+			name: "Escaping",
+			raw:  `<p title="x">a &lt;b&gt; &amp; "c"</p><img alt="&quot;><script>" src="https://a.com/?a=1&amp;b=2">`,
+			want: `<p>a &lt;b&gt; &amp; &#34;c&#34;</p><img src="https://a.com/?a=1&amp;b=2" alt="&#34;&gt;&lt;script&gt;">`,
+		},
+		{
+			name: "Plain_Text",
+			raw:  "Просто текст",
+			want: "Просто текст",
+		},
+		{
+			name: "Empty",
+			raw:  "<p> </p><script>alert(1)</script>",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := HTML(tt.raw, tt.link); got != tt.want {
+				t.Errorf("HTML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,581 @@
+// Пакет для работы с сервером и обработчиками API.
+package server
+
+import (
+	"GoNews/internal/discover"
+	"GoNews/internal/logger"
+	"GoNews/internal/middleware"
+	"GoNews/internal/opml"
+	"GoNews/internal/parser"
+	"GoNews/internal/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FeedManager - интерфейс управления опросом RSS лент во время работы
+// сервиса. Реализуется парсером.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.44.1 --name=FeedManager
+type FeedManager interface {
+	AddFeed(ctx context.Context, feed storage.Feed) (storage.Feed, error)
+	UpdateFeed(ctx context.Context, feed storage.Feed) error
+	RemoveFeed(ctx context.Context, id string) error
+	Discover(ctx context.Context, url string) ([]discover.Feed, error)
+}
+
+// maxOPMLSize - максимальный размер документа OPML в запросе импорта.
+const maxOPMLSize = 1 << 20
+
+// maxFeedSize - максимальный размер тела запроса на добавление или
+// изменение ленты.
+const maxFeedSize = 1 << 16
+
+// FeedRequest - тело запроса на добавление или изменение ленты. Период
+// и таймаут передаются строками в формате time.ParseDuration, например
+// "30m". При изменении ленты меняются только переданные поля, при
+// добавлении лента по умолчанию включена.
+type FeedRequest struct {
+	URL      *string           `json:"url"`
+	Name     *string           `json:"name"`
+	Group    *string           `json:"group"`
+	Period   *string           `json:"period"`
+	Timeout  *string           `json:"timeout"`
+	Enabled  *bool             `json:"enabled"`
+	FullText *bool             `json:"fullText"`
+	Headers  map[string]string `json:"headers"`
+	Auth     *storage.FeedAuth `json:"auth"`
+}
+
+// FeedResponse - структура ленты в ответах API администратора. Учетные
+// данные ленты в ответ не попадают, пароль в адресе ленты заменяется
+// на "xxxxx". State - состояние опроса ленты, пустое, если лента еще
+// не опрашивалась.
+type FeedResponse struct {
+	ID       string             `json:"id"`
+	URL      string             `json:"url"`
+	Name     string             `json:"name"`
+	Group    string             `json:"group,omitempty"`
+	Period   string             `json:"period,omitempty"`
+	Timeout  string             `json:"timeout,omitempty"`
+	Enabled  bool               `json:"enabled"`
+	FullText bool               `json:"fullText"`
+	Headers  map[string]string  `json:"headers,omitempty"`
+	State    *FeedStateResponse `json:"state,omitempty"`
+}
+
+// FeedStateResponse - структура состояния опроса ленты в ответах API
+// администратора. Учетные данные ленты в тексте последней ошибки
+// заменяются на "xxxxx".
+type FeedStateResponse struct {
+	Health      string    `json:"health"`
+	Failures    int       `json:"failures"`
+	NotModified int       `json:"notModified"`
+	LastError   string    `json:"lastError,omitempty"`
+	LastSuccess time.Time `json:"lastSuccess"`
+	LastFailure time.Time `json:"lastFailure"`
+	NextAttempt time.Time `json:"nextAttempt"`
+}
+
+// AdminFeeds записывает в ResponseWriter список всех лент с состоянием
+// их опроса в формате JSON.
+func AdminFeeds(st storage.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.AdminFeeds"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to receive feeds")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		ctx := r.Context()
+		feeds, err := st.Feeds(ctx)
+		if err != nil {
+			log.Error("failed to receive feeds", logger.Err(err))
+			if errors.Is(err, storage.ErrNotFound) {
+				http.Error(w, "feeds not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "failed to receive feeds from DB", http.StatusInternalServerError)
+			return
+		}
+		log.Debug("feeds received successfully", slog.Int("num", len(feeds)))
+
+		// Ленты, которые еще не опрашивались, возвращаются без состояния.
+		states, err := st.FeedStates(ctx)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Error("failed to receive feeds health", logger.Err(err))
+			http.Error(w, "failed to receive feeds health from DB", http.StatusInternalServerError)
+			return
+		}
+		byURL := make(map[string]storage.FeedState, len(states))
+		for _, state := range states {
+			byURL[state.URL] = state
+		}
+
+		resp := make([]FeedResponse, 0, len(feeds))
+		for _, f := range feeds {
+			fr := feedConv(f)
+			if state, ok := byURL[f.URL]; ok {
+				fr.State = stateConv(f, state)
+			}
+			resp = append(resp, fr)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(resp)
+		if err != nil {
+			log.Error("failed to encode feeds", logger.Err(err))
+			http.Error(w, "failed to encode feeds", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("request served successfuly")
+	}
+}
+
+// AdminAddFeed добавляет новую ленту и сразу запускает ее опрос. Если
+// передан адрес страницы сайта, а не ленты, добавляется первая лента,
+// найденная на этой странице. Поиск лент ограничен временем timeout,
+// по истечении которого адрес добавляется как есть. Записывает
+// в ResponseWriter добавленную ленту в формате JSON.
+func AdminAddFeed(fm FeedManager, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.AdminAddFeed"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to add feed")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		var req FeedRequest
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFeedSize)).Decode(&req)
+		if err != nil {
+			log.Error("incorrect request body", slog.String("path", r.URL.Path), logger.Err(err))
+			http.Error(w, "incorrect request body", http.StatusBadRequest)
+			return
+		}
+		if req.URL == nil {
+			log.Error("feed url is missing")
+			http.Error(w, "incorrect request body", http.StatusBadRequest)
+			return
+		}
+
+		feed := storage.Feed{Enabled: true}
+		err = req.apply(&feed)
+		if err != nil {
+			log.Error("incorrect request body", slog.String("path", r.URL.Path), logger.Err(err))
+			http.Error(w, "incorrect request body", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		found, err := discoverFeeds(ctx, fm, feed.URL, timeout)
+		if err == nil && len(found) == 0 {
+			err = discover.ErrNoFeeds
+		}
+		switch {
+		case err == nil:
+			if found[0].URL != feed.URL {
+				log.Info("feed discovered", slog.String("page", feed.URL), slog.String("url", found[0].URL))
+			}
+			feed.URL = found[0].URL
+		case errors.Is(err, parser.ErrInvalidURL), errors.Is(err, discover.ErrNoFeeds):
+			log.Error("failed to discover feed", slog.String("url", feed.URL), logger.Err(err))
+			feedError(w, err)
+			return
+		default:
+			// Ресурс может быть временно недоступен или требовать
+			// авторизации, поэтому адрес добавляется как есть.
+			log.Warn("failed to discover feed, adding url as is", slog.String("url", feed.URL), logger.Err(err))
+		}
+
+		feed, err = fm.AddFeed(ctx, feed)
+		if err != nil {
+			log.Error("failed to add feed", slog.String("url", feed.URL), logger.Err(err))
+			feedError(w, err)
+			return
+		}
+		log.Debug("feed added successfully", slog.String("id", feed.ID))
+
+		w.WriteHeader(http.StatusCreated)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(feedConv(feed))
+		if err != nil {
+			log.Error("failed to encode feed", logger.Err(err))
+			return
+		}
+
+		log.Info("request served successfuly", slog.String("id", feed.ID))
+	}
+}
+
+// AdminUpdateFeed изменяет переданные поля ленты с ID из пути запроса
+// и перезапускает ее опрос. Поле enabled позволяет приостановить
+// и возобновить опрос ленты. Записывает в ResponseWriter измененную
+// ленту в формате JSON.
+func AdminUpdateFeed(st storage.DB, fm FeedManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.AdminUpdateFeed"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to update feed")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		id := r.PathValue("id")
+		var req FeedRequest
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFeedSize)).Decode(&req)
+		if err != nil {
+			log.Error("incorrect request body", slog.String("id", id), logger.Err(err))
+			http.Error(w, "incorrect request body", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		feed, err := st.FeedById(ctx, id)
+		if err != nil {
+			log.Error("failed to receive feed by id", slog.String("id", id), logger.Err(err))
+			feedError(w, err)
+			return
+		}
+
+		err = req.apply(&feed)
+		if err != nil {
+			log.Error("incorrect request body", slog.String("id", id), logger.Err(err))
+			http.Error(w, "incorrect request body", http.StatusBadRequest)
+			return
+		}
+
+		err = fm.UpdateFeed(ctx, feed)
+		if err != nil {
+			log.Error("failed to update feed", slog.String("id", id), logger.Err(err))
+			feedError(w, err)
+			return
+		}
+		log.Debug("feed updated successfully", slog.String("id", id))
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(feedConv(feed))
+		if err != nil {
+			log.Error("failed to encode feed", logger.Err(err))
+			http.Error(w, "failed to encode feed", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("request served successfuly", slog.String("id", id))
+	}
+}
+
+// AdminRemoveFeed останавливает опрос ленты с ID из пути запроса
+// и удаляет ее. Посты ленты остаются в БД.
+func AdminRemoveFeed(fm FeedManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.AdminRemoveFeed"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to remove feed")
+
+		id := r.PathValue("id")
+		ctx := r.Context()
+		err := fm.RemoveFeed(ctx, id)
+		if err != nil {
+			log.Error("failed to remove feed", slog.String("id", id), logger.Err(err))
+			feedError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		log.Info("request served successfuly", slog.String("id", id))
+	}
+}
+
+// AdminExportOPML записывает в ResponseWriter все ленты в виде документа
+// OPML 2.0 с папками по группам лент.
+func AdminExportOPML(st storage.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.AdminExportOPML"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to export feeds")
+
+		ctx := r.Context()
+		feeds, err := st.Feeds(ctx)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Error("failed to receive feeds", logger.Err(err))
+			http.Error(w, "failed to receive feeds from DB", http.StatusInternalServerError)
+			return
+		}
+		log.Debug("feeds received successfully", slog.Int("num", len(feeds)))
+
+		w.Header().Set("Content-Type", "text/x-opml+xml; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="feeds.opml"`)
+		err = opml.Write(w, "GoNews feeds", feeds)
+		if err != nil {
+			log.Error("failed to encode feeds", logger.Err(err))
+			return
+		}
+
+		log.Info("request served successfuly")
+	}
+}
+
+// AdminImportOPML добавляет ленты из документа OPML в теле запроса
+// и запускает их опрос. Записывает в ResponseWriter результат по каждой
+// ленте документа в формате JSON.
+func AdminImportOPML(fm FeedManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.AdminImportOPML"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to import feeds")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		feeds, err := opml.Parse(http.MaxBytesReader(w, r.Body, maxOPMLSize))
+		if err != nil {
+			log.Error("incorrect OPML document", logger.Err(err))
+			http.Error(w, "incorrect OPML document", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		res := parser.Import(ctx, feeds, fm.AddFeed)
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(res)
+		if err != nil {
+			log.Error("failed to encode import results", logger.Err(err))
+			http.Error(w, "failed to encode import results", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("request served successfuly", slog.Int("feeds", len(res)))
+	}
+}
+
+// AdminDiscover ищет ленты по адресу страницы сайта из параметра url
+// запроса не дольше времени timeout. Записывает в ResponseWriter
+// найденные ленты в формате JSON.
+func AdminDiscover(fm FeedManager, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.AdminDiscover"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to discover feeds")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		url := r.URL.Query().Get("url")
+		feeds, err := discoverFeeds(r.Context(), fm, url, timeout)
+		if err != nil {
+			log.Error("failed to discover feeds", slog.String("url", url), logger.Err(err))
+			switch {
+			case errors.Is(err, parser.ErrInvalidURL), errors.Is(err, discover.ErrNoFeeds):
+				feedError(w, err)
+			default:
+				http.Error(w, "failed to load page", http.StatusBadGateway)
+			}
+			return
+		}
+		log.Debug("feeds discovered successfully", slog.Int("num", len(feeds)))
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(feeds)
+		if err != nil {
+			log.Error("failed to encode feeds", logger.Err(err))
+			http.Error(w, "failed to encode feeds", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("request served successfuly", slog.String("url", url))
+	}
+}
+
+// apply записывает в ленту переданные в запросе поля.
+func (req FeedRequest) apply(feed *storage.Feed) error {
+	if req.URL != nil {
+		feed.URL = *req.URL
+	}
+	if req.Name != nil {
+		feed.Name = *req.Name
+	}
+	if req.Group != nil {
+		feed.Group = *req.Group
+	}
+	if req.Period != nil {
+		d, err := parseDuration(*req.Period)
+		if err != nil {
+			return fmt.Errorf("period: %w", err)
+		}
+		feed.Period = d
+	}
+	if req.Timeout != nil {
+		d, err := parseDuration(*req.Timeout)
+		if err != nil {
+			return fmt.Errorf("timeout: %w", err)
+		}
+		feed.Timeout = d
+	}
+	if req.Enabled != nil {
+		feed.Enabled = *req.Enabled
+	}
+	if req.FullText != nil {
+		feed.FullText = *req.FullText
+	}
+	if req.Headers != nil {
+		feed.Headers = req.Headers
+	}
+	if req.Auth != nil {
+		feed.Auth = *req.Auth
+	}
+	return nil
+}
+
+// parseDuration разбирает длительность. Пустая строка означает значение
+// по умолчанию, отрицательные значения запрещены.
+func parseDuration(str string) (time.Duration, error) {
+	if str == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %s", str)
+	}
+	return d, nil
+}
+
+// feedConv преобразует ленту из БД в структуру ответа.
+func feedConv(feed storage.Feed) FeedResponse {
+	resp := FeedResponse{
+		ID:       feed.ID,
+		URL:      redactURL(feed.URL),
+		Name:     feed.Name,
+		Group:    feed.Group,
+		Enabled:  feed.Enabled,
+		FullText: feed.FullText,
+		Headers:  feed.Headers,
+	}
+	if feed.Period > 0 {
+		resp.Period = feed.Period.String()
+	}
+	if feed.Timeout > 0 {
+		resp.Timeout = feed.Timeout.String()
+	}
+	return resp
+}
+
+// stateConv преобразует состояние опроса ленты feed в структуру ответа,
+// заменяя учетные данные ленты в тексте последней ошибки.
+func stateConv(feed storage.Feed, state storage.FeedState) *FeedStateResponse {
+	lastErr := state.LastError
+	if lastErr != "" {
+		lastErr = strings.ReplaceAll(lastErr, feed.URL, redactURL(feed.URL))
+		for _, secret := range []string{feed.Auth.Password, feed.Auth.Token} {
+			if secret != "" {
+				lastErr = strings.ReplaceAll(lastErr, secret, "xxxxx")
+			}
+		}
+	}
+	return &FeedStateResponse{
+		Health:      state.Health,
+		Failures:    state.Failures,
+		NotModified: state.NotModified,
+		LastError:   lastErr,
+		LastSuccess: state.LastSuccess,
+		LastFailure: state.LastFailure,
+		NextAttempt: state.NextAttempt,
+	}
+}
+
+// redactURL возвращает адрес raw, в котором пароль заменен
+// на "xxxxx". Адрес без учетных данных и некорректный адрес
+// возвращаются без изменений.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+// discoverFeeds ищет ленты по адресу url не дольше времени timeout.
+// Нулевой timeout не ограничивает поиск сверх ограничений FeedManager.
+func discoverFeeds(ctx context.Context, fm FeedManager, url string, timeout time.Duration) ([]discover.Feed, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return fm.Discover(ctx, url)
+}
+
+// discoverTimeout возвращает время поиска лент в запросах администратора
+// при таймауте записи ответа сервера write. Поиск должен завершиться
+// раньше, чтобы клиент получил ответ, а не разорванное соединение,
+// поэтому пятая часть таймаута остается на запись ленты и ответа.
+// Нулевой таймаут записи не ограничивает поиск.
+func discoverTimeout(write time.Duration) time.Duration {
+	return write - write/5
+}
+
+// feedError записывает в ResponseWriter ошибку операции с лентой
+// с соответствующим ей кодом ответа.
+func feedError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, parser.ErrInvalidURL):
+		http.Error(w, "invalid feed url", http.StatusBadRequest)
+	case errors.Is(err, discover.ErrNoFeeds):
+		http.Error(w, "no feeds found", http.StatusUnprocessableEntity)
+	case errors.Is(err, storage.ErrIncorrectId):
+		http.Error(w, "incorrect feed id", http.StatusBadRequest)
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "feed not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrDuplicate):
+		http.Error(w, "feed already exists", http.StatusConflict)
+	default:
+		http.Error(w, "failed to process feed", http.StatusInternalServerError)
+	}
+}
@@ -2,15 +2,19 @@
 package server
 
 import (
+	"GoNews/internal/lang"
 	"GoNews/internal/logger"
 	"GoNews/internal/middleware"
 	"GoNews/internal/storage"
 	"GoNews/webapp"
 	"encoding/json"
 	"errors"
+	"html"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // RespWeb - структура ответа, которую ожидает клиентское приложение
@@ -40,6 +44,16 @@ type Pagination struct {
 
 const countOnPage int = 15
 
+// Форматы содержания постов в ответах API, задаются параметром format.
+const (
+	// formatText - содержание текстом без разметки в поле content.
+	formatText = "text"
+	// formatHTML - содержание очищенным HTML в поле content.
+	formatHTML = "html"
+	// formatBoth - текст в поле content и HTML в поле contentHtml.
+	formatBoth = "both"
+)
+
 // Index возвращает клиентское приложение.
 func Index() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -105,7 +119,12 @@ func PostsWebApp(st storage.DB) http.HandlerFunc {
 
 // Posts записывает в ResponseWriter ответ Response в формате JSON.
 // Ответ включает в себя объект пагинации и слайс соответствующих
-// запросу постов из БД,
+// запросу постов из БД. Посты можно отфильтровать по источнику
+// параметром source, по языку параметром lang, например ru или en,
+// и по тегу параметром tag.
+// Формат содержания постов задается параметром format: text, html
+// или both. На некорректные lang, format и collapse возвращается
+// 400 Bad Request.
 func Posts(st storage.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const operation = "server.Posts"
@@ -125,11 +144,40 @@ func Posts(st storage.DB) http.HandlerFunc {
 			page = 1
 		}
 		text := r.URL.Query().Get("s")
+		source := r.URL.Query().Get("source")
+		tag := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("tag")))
+		format, ok := contentFormat(r)
+		if !ok {
+			log.Error("incorrect content format", slog.String("format", format))
+			http.Error(w, "incorrect content format", http.StatusBadRequest)
+			return
+		}
+		collapse, ok := collapseParam(r)
+		if !ok {
+			log.Error("incorrect collapse value", slog.String("collapse", r.URL.Query().Get("collapse")))
+			http.Error(w, "incorrect collapse value", http.StatusBadRequest)
+			return
+		}
+		language, ok := langParam(r)
+		if !ok {
+			log.Error("incorrect language", slog.String("lang", r.URL.Query().Get("lang")))
+			http.Error(w, "incorrect language", http.StatusBadRequest)
+			return
+		}
 
-		opt := &storage.Options{}
+		opt := &storage.Options{Collapse: collapse}
 		if text != "" {
 			opt.SearchQuery = text
 		}
+		if source != "" {
+			opt.SourceID = source
+		}
+		if language != "" {
+			opt.Lang = language
+		}
+		if tag != "" {
+			opt.Tag = tag
+		}
 
 		// Получаем общее количество постов, удовлетворяющих запросу.
 		ctx := r.Context()
@@ -172,6 +220,9 @@ func Posts(st storage.DB) http.HandlerFunc {
 		}
 		log.Debug("posts received successfully", slog.Int("num", len(posts)))
 
+		for i := range posts {
+			posts[i] = formatPost(posts[i], format)
+		}
 		resp := Response{Pagination: pg, Posts: posts}
 		enc := json.NewEncoder(w)
 		enc.SetIndent("", "\t")
@@ -187,6 +238,7 @@ func Posts(st storage.DB) http.HandlerFunc {
 }
 
 // PostByID записывает в ResponseWriter один пост по переданному ID.
+// Формат содержания задается параметром format, как в Posts.
 func PostByID(st storage.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const operation = "server.PostByID"
@@ -207,6 +259,12 @@ func PostByID(st storage.DB) http.HandlerFunc {
 			http.Error(w, "incorrect post id", http.StatusBadRequest)
 			return
 		}
+		format, ok := contentFormat(r)
+		if !ok {
+			log.Error("incorrect content format", slog.String("format", format))
+			http.Error(w, "incorrect content format", http.StatusBadRequest)
+			return
+		}
 
 		ctx := r.Context()
 		post, err := st.PostById(ctx, id)
@@ -227,7 +285,7 @@ func PostByID(st storage.DB) http.HandlerFunc {
 
 		enc := json.NewEncoder(w)
 		enc.SetIndent("", "\t")
-		err = enc.Encode(post)
+		err = enc.Encode(formatPost(post, format))
 		if err != nil {
 			log.Error("failed to encode post", logger.Err(err))
 			http.Error(w, "failed to encode post", http.StatusInternalServerError)
@@ -238,6 +296,229 @@ func PostByID(st storage.DB) http.HandlerFunc {
 	}
 }
 
+// RevisionsResponse - структура ответа с историей изменений поста:
+// текущая версия и прежние версии, начиная с последней.
+type RevisionsResponse struct {
+	Post      storage.Post       `json:"post"`
+	Revisions []storage.Revision `json:"revisions"`
+}
+
+// PostRevisions записывает в ResponseWriter пост с переданным ID
+// и его прежние версии в формате JSON. Если пост не менялся, список
+// версий пуст.
+func PostRevisions(st storage.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.PostRevisions"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to receive post revisions")
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+
+		id := r.PathValue("id")
+		format, ok := contentFormat(r)
+		if !ok {
+			log.Error("incorrect content format", slog.String("format", format))
+			http.Error(w, "incorrect content format", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		post, err := st.PostById(ctx, id)
+		if err != nil {
+			log.Error("failed to receive post by id", slog.String("id", id), logger.Err(err))
+			if errors.Is(err, storage.ErrNotFound) {
+				http.Error(w, "post not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, storage.ErrIncorrectId) {
+				http.Error(w, "incorrect post id", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "failed to receive post", http.StatusInternalServerError)
+			return
+		}
+
+		revs, err := st.Revisions(ctx, id)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Error("failed to receive post revisions", slog.String("id", id), logger.Err(err))
+			http.Error(w, "failed to receive post revisions", http.StatusInternalServerError)
+			return
+		}
+		if revs == nil {
+			revs = []storage.Revision{}
+		}
+		log.Debug("post revisions received successfully", slog.String("id", id), slog.Int("num", len(revs)))
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(RevisionsResponse{Post: formatPost(post, format), Revisions: revs})
+		if err != nil {
+			log.Error("failed to encode post revisions", logger.Err(err))
+			http.Error(w, "failed to encode post revisions", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("request served successfuly", slog.String("id", id))
+	}
+}
+
+// Sources записывает в ResponseWriter список всех источников постов
+// в формате JSON.
+func Sources(st storage.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.Sources"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to receive sources")
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+
+		ctx := r.Context()
+		sources, err := st.Sources(ctx)
+		if err != nil {
+			log.Error("failed to receive sources", logger.Err(err))
+			if errors.Is(err, storage.ErrNotFound) {
+				http.Error(w, "sources not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "failed to receive sources from DB", http.StatusInternalServerError)
+			return
+		}
+		log.Debug("sources received successfully", slog.Int("num", len(sources)))
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(sources)
+		if err != nil {
+			log.Error("failed to encode sources", logger.Err(err))
+			http.Error(w, "failed to encode sources", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("request served successfuly")
+	}
+}
+
+// Tags записывает в ResponseWriter список тегов постов с количеством
+// постов по каждому тегу в формате JSON, начиная с самых частых.
+func Tags(st storage.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.Tags"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to receive tags")
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+
+		ctx := r.Context()
+		tags, err := st.Tags(ctx)
+		if err != nil {
+			log.Error("failed to receive tags", logger.Err(err))
+			if errors.Is(err, storage.ErrNotFound) {
+				http.Error(w, "tags not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "failed to receive tags from DB", http.StatusInternalServerError)
+			return
+		}
+		log.Debug("tags received successfully", slog.Int("num", len(tags)))
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(tags)
+		if err != nil {
+			log.Error("failed to encode tags", logger.Err(err))
+			http.Error(w, "failed to encode tags", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("request served successfuly")
+	}
+}
+
+// contentFormat возвращает формат содержания постов из параметра
+// format запроса, по умолчанию formatText. Второе значение сообщает,
+// что формат известен.
+func contentFormat(r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		return formatText, true
+	case formatText, formatHTML, formatBoth:
+		return format, true
+	}
+	return format, false
+}
+
+// collapseParam возвращает значение параметра collapse запроса,
+// по умолчанию false. Второе значение сообщает, что значение
+// корректно.
+func collapseParam(r *http.Request) (bool, bool) {
+	v := r.URL.Query().Get("collapse")
+	if v == "" {
+		return false, true
+	}
+	collapse, err := strconv.ParseBool(v)
+	return collapse, err == nil
+}
+
+// langParam возвращает код языка из параметра lang запроса, пустой,
+// если параметр не передан. Второе значение сообщает, что значение
+// корректно.
+func langParam(r *http.Request) (string, bool) {
+	v := r.URL.Query().Get("lang")
+	if v == "" {
+		return "", true
+	}
+	code := lang.Code(v)
+	return code, code != ""
+}
+
+// formatPost приводит содержание поста к формату format. Если у поста
+// нет HTML, в формате formatHTML возвращается текст поста, абзацы
+// которого обернуты в теги p.
+func formatPost(p storage.Post, format string) storage.Post {
+	switch format {
+	case formatText:
+		p.ContentHTML = ""
+	case formatHTML:
+		if p.ContentHTML == "" {
+			p.ContentHTML = textHTML(p.Content)
+		}
+		p.Content, p.ContentHTML = p.ContentHTML, ""
+	}
+	return p
+}
+
+// textHTML преобразует текст в HTML, оборачивая непустые строки в теги p.
+func textHTML(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		b.WriteString("<p>" + html.EscapeString(line) + "</p>")
+	}
+	return b.String()
+}
+
 // respConv преобразует получаемые из БД посты в структуры
 // для клиентского приложения.
 func respConv(posts []storage.Post) []RespWeb {
@@ -253,3 +534,92 @@ func respConv(posts []storage.Post) []RespWeb {
 	}
 	return resp
 }
+
+// FeedHealth - структура ответа с работоспособностью RSS ленты. Адрес
+// ленты, текст последней ошибки и заголовки условных запросов могут
+// содержать учетные данные, поэтому в ответ не передаются и доступны
+// только через API администратора. Лента определяется своим ID.
+type FeedHealth struct {
+	FeedID      string    `json:"feedId"`
+	Health      string    `json:"health"`
+	Failures    int       `json:"failures"`
+	NotModified int       `json:"notModified"`
+	NextAttempt time.Time `json:"nextAttempt"`
+}
+
+// FeedsHealth записывает в ResponseWriter состояния опроса всех RSS лент
+// в формате JSON: работоспособность, количество ошибок подряд, количество
+// ответов 304 Not Modified и время следующего опроса.
+func FeedsHealth(st storage.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.FeedsHealth"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		log.Info("request to receive feeds health")
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+
+		ctx := r.Context()
+		states, err := st.FeedStates(ctx)
+		if err != nil {
+			log.Error("failed to receive feeds health", logger.Err(err))
+			if errors.Is(err, storage.ErrNotFound) {
+				http.Error(w, "feeds not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "failed to receive feeds health from DB", http.StatusInternalServerError)
+			return
+		}
+		log.Debug("feeds health received successfully", slog.Int("num", len(states)))
+
+		// Состояния удаленных лент в ответ не попадают.
+		feeds, err := st.Feeds(ctx)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Error("failed to receive feeds", logger.Err(err))
+			http.Error(w, "failed to receive feeds from DB", http.StatusInternalServerError)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(healthConv(states, feeds))
+		if err != nil {
+			log.Error("failed to encode feeds health", logger.Err(err))
+			http.Error(w, "failed to encode feeds health", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info("request served successfuly")
+	}
+}
+
+// healthConv преобразует состояния опроса лент в структуры ответа,
+// сопоставляя состояния с лентами по адресу. Состояния, для которых
+// нет ленты, пропускаются.
+func healthConv(states []storage.FeedState, feeds []storage.Feed) []FeedHealth {
+	ids := make(map[string]string, len(feeds))
+	for _, feed := range feeds {
+		ids[feed.URL] = feed.ID
+	}
+
+	resp := make([]FeedHealth, 0, len(states))
+	for _, st := range states {
+		id, ok := ids[st.URL]
+		if !ok {
+			continue
+		}
+		resp = append(resp, FeedHealth{
+			FeedID:      id,
+			Health:      st.Health,
+			Failures:    st.Failures,
+			NotModified: st.NotModified,
+			NextAttempt: st.NextAttempt,
+		})
+	}
+	return resp
+}
@@ -22,9 +22,21 @@ import (
 
 // posts - тестовые данные.
 var posts = []storage.Post{
-	{ID: "1", Title: "Title one", Content: "Content 1", PubTime: time.Now(), Link: "https://google.com"},
-	{ID: "2", Title: "Title two", Content: "Content 3", PubTime: time.Now(), Link: "https://ya.ru"},
-	{ID: "3", Title: "Title three", Content: "Content 3", PubTime: time.Now(), Link: "https://bing.com"},
+	{ID: "1", SourceID: "1", Title: "Title one", Content: "Content 1", PubTime: time.Now(), Link: "https://google.com"},
+	{ID: "2", SourceID: "2", Title: "Title two", Content: "Content 3", PubTime: time.Now(), Link: "https://ya.ru"},
+	{ID: "3", SourceID: "1", Title: "Title three", Content: "Content 3", PubTime: time.Now(), Link: "https://bing.com"},
+}
+
+// sources - тестовые источники.
+var sources = []storage.Source{
+	{ID: "1", URL: "https://google.com/rss", Title: "Google"},
+	{ID: "2", URL: "https://ya.ru/rss", Title: "Yandex"},
+}
+
+// feedStates - тестовые состояния опроса лент.
+var feedStates = []storage.FeedState{
+	{URL: "https://google.com/rss", Health: storage.HealthHealthy},
+	{URL: "https://ya.ru/rss", Health: storage.HealthFailing, Failures: 4, LastError: "connection refused"},
 }
 
 func TestIndex(t *testing.T) {
@@ -188,6 +200,7 @@ func TestPosts(t *testing.T) {
 		name      string
 		uri       string
 		wantURL   []string
+		wantAlso  int
 		respError string
 		mockError error
 	}{
@@ -226,6 +239,13 @@ func TestPosts(t *testing.T) {
 			respError: "",
 			mockError: nil,
 		},
+		{
+			name:      "OK_With_source",
+			uri:       "/news?source=2",
+			wantURL:   []string{"https://ya.ru"},
+			respError: "",
+			mockError: nil,
+		},
 		{
 			name:      "Incorrect_GET_request",
 			uri:       "/news?page=asdf",
@@ -233,6 +253,57 @@ func TestPosts(t *testing.T) {
 			respError: "",
 			mockError: nil,
 		},
+		{
+			name:      "OK_With_lang",
+			uri:       "/news?lang=ru-RU",
+			wantURL:   []string{"https://ya.ru"},
+			respError: "",
+			mockError: nil,
+		},
+		{
+			name:      "Incorrect_lang",
+			uri:       "/news?lang=xyz1",
+			wantURL:   nil,
+			respError: "incorrect language",
+			mockError: nil,
+		},
+		{
+			name:      "OK_With_tag",
+			uri:       "/news?tag=Generics",
+			wantURL:   []string{"https://ya.ru"},
+			respError: "",
+			mockError: nil,
+		},
+		{
+			// Второй пост относится к сюжету первого.
+			name:      "OK_With_collapse",
+			uri:       "/news?collapse=true",
+			wantURL:   []string{"https://google.com", "https://bing.com"},
+			wantAlso:  1,
+			respError: "",
+			mockError: nil,
+		},
+		{
+			name:      "Incorrect_collapse",
+			uri:       "/news?collapse=maybe",
+			wantURL:   nil,
+			respError: "incorrect collapse value",
+			mockError: nil,
+		},
+		{
+			name:      "OK_With_format",
+			uri:       "/news?format=html",
+			wantURL:   []string{"https://google.com", "https://ya.ru", "https://bing.com"},
+			respError: "",
+			mockError: nil,
+		},
+		{
+			name:      "Incorrect_format",
+			uri:       "/news?format=xml",
+			wantURL:   nil,
+			respError: "incorrect content format",
+			mockError: nil,
+		},
 		{
 			name:      "DB_error",
 			uri:       "/news",
@@ -265,6 +336,12 @@ func TestPosts(t *testing.T) {
 						if q[0] == nil {
 							return 3, tt.mockError
 						}
+						if q[0].Collapse {
+							return 2, tt.mockError
+						}
+						if q[0].SourceID == "2" || q[0].Lang == "ru" || q[0].Tag == "generics" {
+							return 1, tt.mockError
+						}
 						text := q[0].SearchQuery
 						switch text {
 						case "one":
@@ -286,6 +363,19 @@ func TestPosts(t *testing.T) {
 						if q[0] == nil {
 							return posts, tt.mockError
 						}
+						if q[0].Collapse {
+							first := posts[0]
+							first.AlsoCoveredBy = []storage.Coverage{{
+								ID:       posts[1].ID,
+								SourceID: posts[1].SourceID,
+								Title:    posts[1].Title,
+								Link:     posts[1].Link,
+							}}
+							return []storage.Post{first, posts[2]}, tt.mockError
+						}
+						if q[0].SourceID == "2" || q[0].Lang == "ru" || q[0].Tag == "generics" {
+							return posts[1:2], tt.mockError
+						}
 						text := q[0].SearchQuery
 						switch text {
 						case "one":
@@ -329,15 +419,21 @@ func TestPosts(t *testing.T) {
 				t.Fatalf("Posts() error = cannot unmarshal response")
 			}
 
-			// Проверим только совпадение ссылок.
+			// Проверим только совпадение ссылок и число постов других
+			// источников в свернутых сюжетах.
 			urls := []string{}
+			var also int
 			for _, v := range resp.Posts {
 				urls = append(urls, v.Link)
+				also += len(v.AlsoCoveredBy)
 			}
 
 			if !reflect.DeepEqual(urls, tt.wantURL) {
 				t.Errorf("Posts() = %v, want %v", urls, tt.wantURL)
 			}
+			if also != tt.wantAlso {
+				t.Errorf("Posts() also covered by = %v, want %v", also, tt.wantAlso)
+			}
 		})
 	}
 }
@@ -439,6 +535,447 @@ func TestPostByID(t *testing.T) {
 	}
 }
 
+func TestPostRevisions(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	revs := []storage.Revision{
+		{ID: "2", PostID: "1", Title: "Second revision"},
+		{ID: "1", PostID: "1", Title: "First revision"},
+	}
+
+	tests := []struct {
+		name          string
+		revs          []storage.Revision
+		wantCode      int
+		wantRevisions int
+		postError     error
+		revError      error
+	}{
+		{
+			name:          "OK",
+			revs:          revs,
+			wantCode:      http.StatusOK,
+			wantRevisions: 2,
+		},
+		{
+			// Пост не менялся.
+			name:     "No_Revisions",
+			wantCode: http.StatusOK,
+			revError: storage.ErrNotFound,
+		},
+		{
+			name:      "Post_Not_Found",
+			wantCode:  http.StatusNotFound,
+			postError: storage.ErrNotFound,
+		},
+		{
+			name:      "Incorrect_ID",
+			wantCode:  http.StatusBadRequest,
+			postError: storage.ErrIncorrectId,
+		},
+		{
+			name:     "DB_error",
+			wantCode: http.StatusInternalServerError,
+			revError: errors.New("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stMock := mocks.NewDB(t)
+			stMock.
+				On("PostById", mock.Anything, "1").
+				Return(posts[0], tt.postError).
+				Once()
+			if tt.postError == nil {
+				stMock.
+					On("Revisions", mock.Anything, "1").
+					Return(tt.revs, tt.revError).
+					Once()
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /news/id/{id}/revisions", PostRevisions(stMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/news/id/1/revisions", nil)
+			rr := httptest.NewRecorder()
+
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("PostRevisions() status = %d, want %d", rr.Code, tt.wantCode)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var resp RevisionsResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &resp)
+			if err != nil {
+				t.Fatalf("PostRevisions() error = cannot unmarshal response")
+			}
+			if resp.Post.Link != posts[0].Link || len(resp.Revisions) != tt.wantRevisions {
+				t.Errorf("PostRevisions() = %+v, want post %v with %d revisions", resp, posts[0].Link, tt.wantRevisions)
+			}
+			// Пустой список версий кодируется как [], а не null.
+			if tt.wantRevisions == 0 && !strings.Contains(rr.Body.String(), `"revisions": []`) {
+				t.Errorf("PostRevisions() body = %s, want empty revisions", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSources(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		want      []string
+		respError string
+		mockError error
+	}{
+		{
+			name:      "OK",
+			want:      []string{"Google", "Yandex"},
+			respError: "",
+			mockError: nil,
+		},
+		{
+			name:      "Error_not_found",
+			want:      nil,
+			respError: "sources not found",
+			mockError: storage.ErrNotFound,
+		},
+		{
+			name:      "DB_error",
+			want:      nil,
+			respError: "failed to receive sources from DB",
+			mockError: errors.New("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stMock := mocks.NewDB(t)
+			stMock.
+				On("Sources", mock.Anything).
+				Return(func(ctx context.Context) ([]storage.Source, error) {
+					if tt.mockError != nil {
+						return nil, tt.mockError
+					}
+					return sources, nil
+				}).
+				Once()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /sources", Sources(stMock))
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			req := httptest.NewRequest(http.MethodGet, "/sources", nil)
+			rr := httptest.NewRecorder()
+
+			mux.ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+
+			if rr.Code != http.StatusOK {
+				// Проверяем тело ответа и проваливаем тест, если содержимое
+				// не совпадает с нашей ожидаемой ошибкой.
+				body = strings.ReplaceAll(body, "\n", "")
+				if body == tt.respError {
+					t.SkipNow()
+				}
+				t.Fatalf("Sources() error = %s, want %s", body, tt.respError)
+			}
+
+			resp := []storage.Source{}
+			err := json.Unmarshal([]byte(body), &resp)
+			if err != nil {
+				t.Fatalf("Sources() error = cannot unmarshal response")
+			}
+
+			// Проверим только совпадение названий.
+			titles := []string{}
+			for _, v := range resp {
+				titles = append(titles, v.Title)
+			}
+
+			if !reflect.DeepEqual(titles, tt.want) {
+				t.Errorf("Sources() = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	tags := []storage.TagCount{{Tag: "go", Count: 10}, {Tag: "generics", Count: 2}}
+
+	tests := []struct {
+		name      string
+		want      []storage.TagCount
+		respError string
+		mockError error
+	}{
+		{
+			name:      "OK",
+			want:      tags,
+			respError: "",
+			mockError: nil,
+		},
+		{
+			name:      "Error_not_found",
+			want:      nil,
+			respError: "tags not found",
+			mockError: storage.ErrNotFound,
+		},
+		{
+			name:      "DB_error",
+			want:      nil,
+			respError: "failed to receive tags from DB",
+			mockError: errors.New("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stMock := mocks.NewDB(t)
+			stMock.
+				On("Tags", mock.Anything).
+				Return(func(ctx context.Context) ([]storage.TagCount, error) {
+					if tt.mockError != nil {
+						return nil, tt.mockError
+					}
+					return tags, nil
+				}).
+				Once()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /tags", Tags(stMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+			rr := httptest.NewRecorder()
+
+			mux.ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+
+			if rr.Code != http.StatusOK {
+				body = strings.ReplaceAll(body, "\n", "")
+				if body == tt.respError {
+					t.SkipNow()
+				}
+				t.Fatalf("Tags() error = %s, want %s", body, tt.respError)
+			}
+
+			var resp []storage.TagCount
+			err := json.Unmarshal([]byte(body), &resp)
+			if err != nil {
+				t.Fatalf("Tags() error = cannot unmarshal response")
+			}
+			if !reflect.DeepEqual(resp, tt.want) {
+				t.Errorf("Tags() = %v, want %v", resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedsHealth(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		want      []FeedHealth
+		respError string
+		mockError error
+		feeds     []storage.Feed
+		feedError error
+	}{
+		{
+			name: "OK",
+			want: []FeedHealth{
+				{FeedID: "1", Health: storage.HealthHealthy},
+				{FeedID: "2", Health: storage.HealthFailing, Failures: 4},
+			},
+			respError: "",
+			mockError: nil,
+			feeds:     feeds,
+			feedError: nil,
+		},
+		{
+			// Состояние удаленной ленты пропускается.
+			name: "OK_removed_feed",
+			want: []FeedHealth{
+				{FeedID: "1", Health: storage.HealthHealthy},
+			},
+			respError: "",
+			mockError: nil,
+			feeds:     feeds[:1],
+			feedError: nil,
+		},
+		{
+			name:      "OK_no_feeds",
+			want:      []FeedHealth{},
+			respError: "",
+			mockError: nil,
+			feedError: storage.ErrNotFound,
+		},
+		{
+			name:      "Error_not_found",
+			want:      nil,
+			respError: "feeds not found",
+			mockError: storage.ErrNotFound,
+			feedError: nil,
+		},
+		{
+			name:      "DB_error",
+			want:      nil,
+			respError: "failed to receive feeds health from DB",
+			mockError: errors.New("DB error"),
+			feedError: nil,
+		},
+		{
+			name:      "Feeds_DB_error",
+			want:      nil,
+			respError: "failed to receive feeds from DB",
+			mockError: nil,
+			feedError: errors.New("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stMock := mocks.NewDB(t)
+			stMock.
+				On("FeedStates", mock.Anything).
+				Return(func(ctx context.Context) ([]storage.FeedState, error) {
+					if tt.mockError != nil {
+						return nil, tt.mockError
+					}
+					return feedStates, nil
+				}).
+				Once()
+			if tt.mockError == nil {
+				stMock.
+					On("Feeds", mock.Anything).
+					Return(func(ctx context.Context) ([]storage.Feed, error) {
+						if tt.feedError != nil {
+							return nil, tt.feedError
+						}
+						return tt.feeds, nil
+					}).
+					Once()
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /feeds/health", FeedsHealth(stMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/feeds/health", nil)
+			rr := httptest.NewRecorder()
+
+			mux.ServeHTTP(rr, req)
+
+			body := rr.Body.String()
+
+			if rr.Code != http.StatusOK {
+				body = strings.ReplaceAll(body, "\n", "")
+				if body == tt.respError {
+					t.SkipNow()
+				}
+				t.Fatalf("FeedsHealth() error = %s, want %s", body, tt.respError)
+			}
+
+			// Адреса лент и тексты ошибок не должны попадать в ответ.
+			if strings.Contains(body, "https://") || strings.Contains(body, "connection refused") {
+				t.Errorf("FeedsHealth() = %s, want no URLs and errors", body)
+			}
+
+			resp := []FeedHealth{}
+			err := json.Unmarshal([]byte(body), &resp)
+			if err != nil {
+				t.Fatalf("FeedsHealth() error = cannot unmarshal response")
+			}
+
+			if !reflect.DeepEqual(resp, tt.want) {
+				t.Errorf("FeedsHealth() = %v, want %v", resp, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatPost(t *testing.T) {
+	t.Parallel()
+
+	post := storage.Post{Content: "Абзац 1\nАбзац <2>", ContentHTML: "<p>Абзац 1</p><p>Абзац &lt;2&gt;</p>"}
+
+	tests := []struct {
+		name     string
+		post     storage.Post
+		format   string
+		wantText string
+		wantHTML string
+	}{
+		{
+			name:     "Text",
+			post:     post,
+			format:   formatText,
+			wantText: post.Content,
+			wantHTML: "",
+		},
+		{
+			name:     "HTML",
+			post:     post,
+			format:   formatHTML,
+			wantText: post.ContentHTML,
+			wantHTML: "",
+		},
+		{
+			name:     "HTML_From_Text",
+			post:     storage.Post{Content: "Абзац 1\n\nАбзац <2>"},
+			format:   formatHTML,
+			wantText: "<p>Абзац 1</p><p>Абзац &lt;2&gt;</p>",
+			wantHTML: "",
+		},
+		{
+			name:     "Both",
+			post:     post,
+			format:   formatBoth,
+			wantText: post.Content,
+			wantHTML: post.ContentHTML,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := formatPost(tt.post, tt.format)
+			if got.Content != tt.wantText || got.ContentHTML != tt.wantHTML {
+				t.Errorf("formatPost() = %q, %q, want %q, %q", got.Content, got.ContentHTML, tt.wantText, tt.wantHTML)
+			}
+		})
+	}
+}
+
 func Test_respConv(t *testing.T) {
 	t.Parallel()
 
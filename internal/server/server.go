@@ -4,6 +4,7 @@ import (
 	"GoNews/internal/config"
 	"GoNews/internal/middleware"
 	"GoNews/internal/storage"
+	"GoNews/internal/websub"
 	"context"
 	"errors"
 	"log"
@@ -56,8 +57,43 @@ func (s *Server) Middleware() {
 func (s *Server) API(st storage.DB) {
 	// s.mux.HandleFunc("GET /", Index())
 	s.mux.HandleFunc("GET /news/id/{id}", PostByID(st))
+	s.mux.HandleFunc("GET /news/id/{id}/revisions", PostRevisions(st))
 	s.mux.HandleFunc("GET /news/{n}", PostsWebApp(st))
 	s.mux.HandleFunc("GET /news", Posts(st))
+	s.mux.HandleFunc("GET /sources", Sources(st))
+	s.mux.HandleFunc("GET /tags", Tags(st))
+	s.mux.HandleFunc("GET /feeds/health", FeedsHealth(st))
+}
+
+// Admin инициализирует обработчики API администратора. Все обработчики
+// требуют токен token в заголовке Authorization. Если токен не задан,
+// API администратора не инициализируется.
+func (s *Server) Admin(st storage.DB, fm FeedManager, token string) {
+	if token == "" {
+		slog.Warn("admin token is empty, admin API is disabled")
+		return
+	}
+
+	var discover time.Duration
+	if s.srv != nil {
+		discover = discoverTimeout(s.srv.WriteTimeout)
+	}
+	admin := http.NewServeMux()
+	admin.HandleFunc("GET /admin/feeds", AdminFeeds(st))
+	admin.HandleFunc("POST /admin/feeds", AdminAddFeed(fm, discover))
+	admin.HandleFunc("PATCH /admin/feeds/{id}", AdminUpdateFeed(st, fm))
+	admin.HandleFunc("DELETE /admin/feeds/{id}", AdminRemoveFeed(fm))
+	admin.HandleFunc("GET /admin/discover", AdminDiscover(fm, discover))
+	admin.HandleFunc("GET /admin/opml", AdminExportOPML(st))
+	admin.HandleFunc("POST /admin/opml", AdminImportOPML(fm))
+	s.mux.Handle("/admin/", middleware.BearerAuth(token, admin))
+}
+
+// WebSub инициализирует обработчики запросов хабов WebSub, через которые
+// хабы подтверждают подписки и доставляют обновления лент.
+func (s *Server) WebSub(ws WebSub) {
+	s.mux.HandleFunc("GET "+websub.CallbackPath+"{id}", WebSubVerify(ws))
+	s.mux.HandleFunc("POST "+websub.CallbackPath+"{id}", WebSubDeliver(ws))
 }
 
 // Shutdown останавливает сервер используя graceful shutdown.
@@ -0,0 +1,119 @@
+// Пакет для работы с сервером и обработчиками API.
+package server
+
+import (
+	"GoNews/internal/logger"
+	"GoNews/internal/middleware"
+	"GoNews/internal/parser"
+	"GoNews/internal/storage"
+	"GoNews/internal/websub"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+// WebSub - интерфейс обработки запросов хабов WebSub к подписчику.
+// Реализуется парсером.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.44.1 --name=WebSub
+type WebSub interface {
+	Verify(ctx context.Context, id string, query url.Values) (string, error)
+	Deliver(ctx context.Context, id, signature string, body []byte, contentType string) error
+}
+
+// maxPushSize - максимальный размер обновления ленты, доставленного хабом.
+const maxPushSize = 10 << 20
+
+// WebSubVerify отвечает на запрос хаба о подтверждении подписки на ленту
+// с ID из пути запроса, записывая в ResponseWriter значение
+// hub.challenge. Неизвестные подписки не подтверждаются.
+func WebSubVerify(ws WebSub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.WebSubVerify"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id := r.PathValue("id")
+		query := r.URL.Query()
+		log.Info("request to verify subscription",
+			slog.String("id", id),
+			slog.String("mode", query.Get("hub.mode")),
+		)
+
+		ctx := r.Context()
+		challenge, err := ws.Verify(ctx, id, query)
+		if err != nil {
+			log.Error("failed to verify subscription", slog.String("id", id), logger.Err(err))
+			switch {
+			case errors.Is(err, websub.ErrBadRequest):
+				http.Error(w, "incorrect verification request", http.StatusBadRequest)
+			case errors.Is(err, websub.ErrUnknownTopic),
+				errors.Is(err, storage.ErrNotFound),
+				errors.Is(err, parser.ErrNoWebSub):
+				http.Error(w, "subscription not found", http.StatusNotFound)
+			default:
+				http.Error(w, "failed to verify subscription", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, challenge)
+		log.Info("request served successfuly", slog.String("id", id))
+	}
+}
+
+// WebSubDeliver принимает от хаба обновление ленты с ID из пути запроса
+// и записывает ее посты в БД. Обновление с неверной подписью
+// отбрасывается, но хабу, как требует спецификация WebSub, возвращается
+// успешный ответ. На обновление удаленной ленты возвращается
+// 410 Gone, чтобы хаб прекратил доставку.
+func WebSubDeliver(ws WebSub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const operation = "server.WebSubDeliver"
+
+		log := slog.Default().With(
+			slog.String("op", operation),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id := r.PathValue("id")
+		log.Info("request to deliver feed update", slog.String("id", id))
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPushSize))
+		if err != nil {
+			log.Error("failed to read feed update", slog.String("id", id), logger.Err(err))
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, "feed update is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "incorrect request body", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		err = ws.Deliver(ctx, id, r.Header.Get("X-Hub-Signature"), body, r.Header.Get("Content-Type"))
+		switch {
+		case errors.Is(err, websub.ErrBadSignature):
+			log.Warn("feed update with invalid signature ignored", slog.String("id", id))
+		case errors.Is(err, storage.ErrNotFound), errors.Is(err, parser.ErrNoWebSub):
+			log.Error("feed update for unknown subscription", slog.String("id", id), logger.Err(err))
+			http.Error(w, "subscription not found", http.StatusGone)
+			return
+		case err != nil:
+			log.Error("failed to deliver feed update", slog.String("id", id), logger.Err(err))
+			http.Error(w, "failed to deliver feed update", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		log.Info("request served successfuly", slog.String("id", id))
+	}
+}
@@ -0,0 +1,144 @@
+// Пакет для работы с сервером и обработчиками API.
+package server
+
+import (
+	"GoNews/internal/logger"
+	"GoNews/internal/mocks"
+	"GoNews/internal/parser"
+	"GoNews/internal/storage"
+	"GoNews/internal/websub"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebSubVerify(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		challenge string
+		wantCode  int
+		mockError error
+	}{
+		{
+			name:      "OK",
+			challenge: "challenge",
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "Unknown_Topic",
+			wantCode:  http.StatusNotFound,
+			mockError: websub.ErrUnknownTopic,
+		},
+		{
+			name:      "Disabled",
+			wantCode:  http.StatusNotFound,
+			mockError: parser.ErrNoWebSub,
+		},
+		{
+			name:      "Bad_Request",
+			wantCode:  http.StatusBadRequest,
+			mockError: websub.ErrBadRequest,
+		},
+		{
+			name:      "DB_error",
+			wantCode:  http.StatusInternalServerError,
+			mockError: errors.New("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wsMock := mocks.NewWebSub(t)
+			wsMock.
+				On("Verify", mock.Anything, "1", mock.AnythingOfType("url.Values")).
+				Return(tt.challenge, tt.mockError).
+				Once()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /websub/{id}", WebSubVerify(wsMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/websub/1?hub.mode=subscribe&hub.challenge=challenge", nil)
+			rr := httptest.NewRecorder()
+
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("WebSubVerify() status = %d, want %d", rr.Code, tt.wantCode)
+			}
+			if tt.mockError == nil && rr.Body.String() != tt.challenge {
+				t.Errorf("WebSubVerify() body = %q, want %q", rr.Body.String(), tt.challenge)
+			}
+		})
+	}
+}
+
+func TestWebSubDeliver(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		wantCode  int
+		mockError error
+	}{
+		{
+			name:     "OK",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			// Обновление с неверной подписью отбрасывается без ошибки.
+			name:      "Bad_Signature",
+			wantCode:  http.StatusNoContent,
+			mockError: websub.ErrBadSignature,
+		},
+		{
+			name:      "Not_Found",
+			wantCode:  http.StatusGone,
+			mockError: storage.ErrNotFound,
+		},
+		{
+			name:      "DB_error",
+			wantCode:  http.StatusInternalServerError,
+			mockError: errors.New("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			const body = `<feed xmlns="http://www.w3.org/2005/Atom"></feed>`
+
+			wsMock := mocks.NewWebSub(t)
+			wsMock.
+				On("Deliver", mock.Anything, "1", "sha256=abc", []byte(body), "application/atom+xml").
+				Return(tt.mockError).
+				Once()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /websub/{id}", WebSubDeliver(wsMock))
+
+			req := httptest.NewRequest(http.MethodPost, "/websub/1", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/atom+xml")
+			req.Header.Set("X-Hub-Signature", "sha256=abc")
+			rr := httptest.NewRecorder()
+
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("WebSubDeliver() status = %d, want %d", rr.Code, tt.wantCode)
+			}
+		})
+	}
+}
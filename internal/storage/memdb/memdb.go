@@ -5,17 +5,23 @@ import (
 	"GoNews/internal/storage"
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"time"
 )
 
 // Storage - эмуляция пула подключений к БД.
 type Storage struct {
 	news []storage.Post
+	// keys - индексы записанных постов в news по ключам повтора
+	// и источникам.
+	keys      map[string]int
+	revisions []storage.Revision
 }
 
 // New - конструктор эмулятора пулов подключений.
 func New() *Storage {
-	return &Storage{}
+	return &Storage{keys: make(map[string]int)}
 }
 
 // Close - эмуляция закрытия пула подключений.
@@ -28,12 +34,104 @@ func (s *Storage) Len() int {
 	return len(s.news)
 }
 
-// AddPost - эмуляция метода добавления постов в БД.
+// AddPost - эмуляция метода добавления постов в БД. Как и в БД, пост
+// с ключом повтора, уже записанным для того же источника, не добавляется,
+// а при изменении заголовка или содержания обновляет записанный пост
+// с сохранением прежней версии. Новый пост относится к сюжету
+// почти одинакового поста другого источника или начинает свой.
 func (s *Storage) AddPosts(ctx context.Context, posts <-chan storage.Post) (int, error) {
+	var n int
 	for p := range posts {
-		s.news = append(s.news, p)
+		key := p.SourceID + "\x00" + storage.DedupKey(p)
+		i, ok := s.keys[key]
+		if !ok {
+			p.Cluster = s.cluster(p)
+			s.keys[key] = len(s.news)
+			s.news = append(s.news, p)
+			n++
+			continue
+		}
+
+		old := s.news[i]
+		p = storage.KeepFullText(old, p)
+		if storage.ContentHash(old) == storage.ContentHash(p) {
+			continue
+		}
+		now := time.Now()
+		created := old.Updated
+		if created.IsZero() {
+			created = old.PubTime
+		}
+		s.revisions = append(s.revisions, storage.Revision{
+			ID:       strconv.Itoa(len(s.revisions) + 1),
+			PostID:   old.ID,
+			Title:    old.Title,
+			Content:  old.Content,
+			Link:     old.Link,
+			Created:  created,
+			Replaced: now,
+		})
+		p.ID = old.ID
+		p.PubTime = old.PubTime
+		p.Updated = now
+		s.news[i] = p
+	}
+	return n, nil
+}
+
+// cluster возвращает сюжет первого записанного почти одинакового
+// поста другого источника или ID поста, если такого поста нет.
+func (s *Storage) cluster(p storage.Post) string {
+	for _, old := range s.news {
+		if !storage.SameStory(p, old) {
+			continue
+		}
+		if old.Cluster != "" {
+			return old.Cluster
+		}
+		return old.ID
+	}
+	return p.ID
+}
+
+// Revisions - эмуляция метода получения прежних версий поста из БД.
+func (s *Storage) Revisions(ctx context.Context, postID string) ([]storage.Revision, error) {
+	var revs []storage.Revision
+	for i := len(s.revisions) - 1; i >= 0; i-- {
+		if s.revisions[i].PostID == postID {
+			revs = append(revs, s.revisions[i])
+		}
+	}
+	if len(revs) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return revs, nil
+}
+
+// Tags - эмуляция метода получения тегов постов с количеством постов
+// по каждому тегу.
+func (s *Storage) Tags(ctx context.Context) ([]storage.TagCount, error) {
+	counts := make(map[string]int64)
+	for _, p := range s.news {
+		for _, t := range p.Tags {
+			counts[t]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil, storage.ErrNotFound
+	}
+
+	tags := make([]storage.TagCount, 0, len(counts))
+	for t, n := range counts {
+		tags = append(tags, storage.TagCount{Tag: t, Count: n})
 	}
-	return len(s.news), nil
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags, nil
 }
 
 // Posts - эмуляция метода получения постов из БД.
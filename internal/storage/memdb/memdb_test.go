@@ -3,15 +3,26 @@
 package memdb
 
 import (
+	"GoNews/internal/minhash"
 	"GoNews/internal/storage"
 	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var posts = []storage.Post{
 	{Title: "First post"},
 	{Title: "Second post"},
+	// Повтор первого поста.
+	{Title: "First post"},
+	// Исправленный заголовок поста с тем же GUID.
+	{SourceID: "1", Title: "Go Weekly", GUID: "1"},
+	{SourceID: "1", Title: "Go Weekly #1", GUID: "1"},
+	// Одинаковые заголовки в разных источниках.
+	{SourceID: "1", Title: "Go Weekly", Link: "https://golangweekly.com/1"},
+	{SourceID: "2", Title: "Go Weekly", Link: "https://golangweekly.com/1"},
 }
 
 func TestStorage_AddPosts(t *testing.T) {
@@ -39,7 +50,7 @@ func TestStorage_AddPosts(t *testing.T) {
 			name: "OK",
 			s:    st,
 			args: args{ctx: context.Background(), posts: ch},
-			want: 2,
+			want: 5,
 		},
 	}
 	for _, tt := range tests {
@@ -51,6 +62,113 @@ func TestStorage_AddPosts(t *testing.T) {
 	}
 }
 
+func TestStorage_Revisions(t *testing.T) {
+	t.Parallel()
+
+	st := New()
+	add := func(p storage.Post) {
+		ch := make(chan storage.Post, 1)
+		ch <- p
+		close(ch)
+		st.AddPosts(context.Background(), ch)
+	}
+
+	// Исправленный пост заменяет записанный, прежняя версия
+	// сохраняется.
+	add(storage.Post{ID: "1", SourceID: "1", Title: "Go 1.23", Content: "relased", GUID: "1"})
+	add(storage.Post{SourceID: "1", Title: "Go 1.23", Content: "relased", GUID: "1"})
+	add(storage.Post{SourceID: "1", Title: "Go 1.23", Content: "released", GUID: "1"})
+
+	if st.Len() != 1 || st.news[0].Content != "released" || st.news[0].ID != "1" {
+		t.Errorf("Storage.AddPosts() posts = %+v, want updated post", st.news)
+	}
+	revs, err := st.Revisions(context.Background(), "1")
+	if err != nil || len(revs) != 1 || revs[0].Content != "relased" {
+		t.Errorf("Storage.Revisions() = %+v, %v, want previous version", revs, err)
+	}
+}
+
+func TestStorage_AddPosts_fullText(t *testing.T) {
+	t.Parallel()
+
+	st := New()
+	add := func(p storage.Post) {
+		ch := make(chan storage.Post, 1)
+		ch <- p
+		close(ch)
+		st.AddPosts(context.Background(), ch)
+	}
+
+	// Неудачная повторная загрузка статьи не заменяет записанный
+	// полный текст описанием из ленты и не создает версию.
+	add(storage.Post{ID: "1", SourceID: "1", Title: "Go 1.23", Content: "Полный текст", FullText: true, GUID: "1"})
+	add(storage.Post{SourceID: "1", Title: "Go 1.23", Content: "Читать далее", FullTextFailed: true, GUID: "1"})
+
+	if st.news[0].Content != "Полный текст" || !st.news[0].FullText {
+		t.Errorf("Storage.AddPosts() post = %+v, want stored full text", st.news[0])
+	}
+	if _, err := st.Revisions(context.Background(), "1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.Revisions() error = %v, want %v", err, storage.ErrNotFound)
+	}
+}
+
+func TestStorage_AddPosts_cluster(t *testing.T) {
+	t.Parallel()
+
+	fp := minhash.Signature("Go 1.23 is released", "The Go team has released Go 1.23 with range-over-func iterators.")
+	near := minhash.Signature("Go 1.23 arrives with iterators", "Version 1.23 of the Go programming language adds range over function iterators.")
+	other := minhash.Signature("Kubernetes 1.31 released", "Kubernetes v1.31 ships 45 enhancements.")
+	now := time.Now()
+
+	ch := make(chan storage.Post, 5)
+	ch <- storage.Post{ID: "1", SourceID: "1", GUID: "1", PubTime: now, MinHash: fp}
+	// Пересказ той же новости другим источником.
+	ch <- storage.Post{ID: "2", SourceID: "2", GUID: "2", PubTime: now.Add(time.Hour), MinHash: near}
+	// Пост того же источника относится к сюжету через пост другого
+	// источника.
+	ch <- storage.Post{ID: "3", SourceID: "1", GUID: "3", PubTime: now, MinHash: fp}
+	// Публикация позже storage.ClusterWindow.
+	ch <- storage.Post{ID: "4", SourceID: "3", GUID: "4", PubTime: now.Add(2 * storage.ClusterWindow), MinHash: fp}
+	// Другой сюжет.
+	ch <- storage.Post{ID: "5", SourceID: "4", GUID: "5", PubTime: now, MinHash: other}
+	close(ch)
+
+	st := New()
+	st.AddPosts(context.Background(), ch)
+
+	want := []string{"1", "1", "1", "4", "5"}
+	var got []string
+	for _, p := range st.news {
+		got = append(got, p.Cluster)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Storage.AddPosts() clusters = %v, want %v", got, want)
+	}
+}
+
+func TestStorage_Tags(t *testing.T) {
+	t.Parallel()
+
+	st := New()
+	if _, err := st.Tags(context.Background()); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.Tags() error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	ch := make(chan storage.Post, 3)
+	ch <- storage.Post{SourceID: "1", GUID: "1", Tags: []string{"generics", "go"}}
+	ch <- storage.Post{SourceID: "1", GUID: "2", Tags: []string{"concurrency", "go"}}
+	ch <- storage.Post{SourceID: "1", GUID: "3"}
+	close(ch)
+	st.AddPosts(context.Background(), ch)
+
+	// Теги отсортированы по убыванию количества постов, затем по названию.
+	want := []storage.TagCount{{Tag: "go", Count: 2}, {Tag: "concurrency", Count: 1}, {Tag: "generics", Count: 1}}
+	got, err := st.Tags(context.Background())
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Storage.Tags() = %v, %v, want %v", got, err, want)
+	}
+}
+
 func TestStorage_Posts(t *testing.T) {
 	t.Parallel()
 
@@ -3,10 +3,13 @@ package mongodb
 
 import (
 	"GoNews/internal/config"
+	"GoNews/internal/minhash"
 	"GoNews/internal/storage"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,12 +22,22 @@ import (
 // а не константы, так как в тестах им присваиваются другие
 // значения.
 var (
-	dbName  string = "goExam"
-	colName string = "posts"
+	dbName       string = "goExam"
+	colName      string = "posts"
+	srcColName   string = "sources"
+	stateColName string = "feedStates"
+	feedColName  string = "feeds"
+	subColName   string = "subscriptions"
+	revColName   string = "revisions"
+	metaColName  string = "meta"
 )
 
 const tmConn time.Duration = time.Second * 20
 
+// maxCandidates - максимальное число постов, сигнатуры которых
+// сравниваются с сигнатурой нового поста при поиске его сюжета.
+const maxCandidates = 50
+
 // Storage - пул подключений к БД.
 type Storage struct {
 	db *mongo.Client
@@ -77,17 +90,104 @@ func new(opts *options.ClientOptions) (*Storage, error) {
 
 	collection := db.Database(dbName).Collection(colName)
 
-	// Создаем уникальный индекс по полю title, чтобы избежать
-	// записи уже существующих постов.
-	indexUniq := mongo.IndexModel{
-		Keys:    bson.D{{Key: "title", Value: -1}},
-		Options: options.Index().SetUnique(true),
-	}
 	// Создаем индекс текстового поиска по полю title.
 	indexText := mongo.IndexModel{
 		Keys: bson.D{{Key: "title", Value: "text"}},
 	}
-	_, err = collection.Indexes().CreateMany(tm, []mongo.IndexModel{indexUniq, indexText})
+	_, err = collection.Indexes().CreateOne(tm, indexText)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Посты фильтруются по языку с сортировкой по дате публикации.
+	indexLang := mongo.IndexModel{
+		Keys: bson.D{{Key: "lang", Value: 1}, {Key: "pubTime", Value: -1}},
+	}
+	_, err = collection.Indexes().CreateOne(tm, indexLang)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Посты фильтруются по тегу с сортировкой по дате публикации.
+	indexTags := mongo.IndexModel{
+		Keys: bson.D{{Key: "tags", Value: 1}, {Key: "pubTime", Value: -1}},
+	}
+	_, err = collection.Indexes().CreateOne(tm, indexTags)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Сюжет нового поста ищется по частям сигнатуры среди постов,
+	// опубликованных в пределах storage.ClusterWindow.
+	indexBands := mongo.IndexModel{
+		Keys: bson.D{{Key: "minBands", Value: 1}, {Key: "pubTime", Value: 1}},
+	}
+	_, err = collection.Indexes().CreateOne(tm, indexBands)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// При свертке сюжетов возвращаются первые посты сюжетов
+	// с сортировкой по дате публикации.
+	indexHead := mongo.IndexModel{
+		Keys: bson.D{{Key: "clusterHead", Value: 1}, {Key: "pubTime", Value: -1}},
+	}
+	_, err = collection.Indexes().CreateOne(tm, indexHead)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Остальные посты сюжетов запрашиваются по сюжету.
+	indexCluster := mongo.IndexModel{
+		Keys: bson.D{{Key: "cluster", Value: 1}},
+	}
+	_, err = collection.Indexes().CreateOne(tm, indexCluster)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Повторы постов определяются ключом, а не заголовком. Миграция
+	// выполняется без таймаута подключения, так как в коллекции
+	// может быть много постов.
+	err = migrateDedup(context.Background(), collection)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	err = migrateClusters(context.Background(), collection)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	err = migrateMinHash(context.Background(), collection)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Версии постов запрашиваются по ID поста.
+	revisions := db.Database(dbName).Collection(revColName)
+	indexPost := mongo.IndexModel{
+		Keys: bson.D{{Key: "postId", Value: 1}, {Key: "replaced", Value: -1}},
+	}
+	_, err = revisions.Indexes().CreateOne(tm, indexPost)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Источники однозначно определяются адресом ленты.
+	sources := db.Database(dbName).Collection(srcColName)
+	indexURL := mongo.IndexModel{
+		Keys:    bson.D{{Key: "url", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err = sources.Indexes().CreateOne(tm, indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	// Ленты также однозначно определяются адресом.
+	feeds := db.Database(dbName).Collection(feedColName)
+	_, err = feeds.Indexes().CreateOne(tm, indexURL)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", operation, err)
 	}
@@ -100,56 +200,531 @@ func (s *Storage) Close() error {
 	return s.db.Disconnect(context.Background())
 }
 
+// migrateDedup переводит коллекцию постов с уникального индекса
+// по заголовку на уникальный индекс по источнику и ключу повтора
+// storage.DedupKey. Индекс частичный, поэтому создается до заполнения
+// ключей у постов, записанных раньше. Если ключ такого поста совпадает
+// с ключом более раннего поста того же источника, пост удаляется как
+// повтор. Повторный запуск миграции ничего не меняет. Посты без
+// источника привязываются к источникам при записи их повторов
+// в adoptLegacy.
+func migrateDedup(ctx context.Context, collection *mongo.Collection) error {
+	// Индекс title_-1 создавался предыдущими версиями сервиса.
+	cur, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	var indexes []bson.M
+	err = cur.All(ctx, &indexes)
+	if err != nil {
+		return err
+	}
+	for _, idx := range indexes {
+		if idx["name"] == "title_-1" {
+			_, err = collection.Indexes().DropOne(ctx, "title_-1")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	indexKey := mongo.IndexModel{
+		Keys: bson.D{{Key: "sourceId", Value: 1}, {Key: "dedupKey", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.D{{Key: "dedupKey", Value: bson.D{{Key: "$exists", Value: true}}}}),
+	}
+	_, err = collection.Indexes().CreateOne(ctx, indexKey)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.D{{Key: "dedupKey", Value: bson.D{{Key: "$exists", Value: false}}}}
+	opts := options.Find().SetSort(bson.D{{Key: "pubTime", Value: 1}})
+	res, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer res.Close(ctx)
+
+	var updated, removed int
+	for res.Next(ctx) {
+		var p storage.Post
+		err = res.Decode(&p)
+		if err != nil {
+			return err
+		}
+
+		obj, err := primitive.ObjectIDFromHex(p.ID)
+		if err != nil {
+			return err
+		}
+		id := bson.D{{Key: "_id", Value: obj}}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "dedupKey", Value: storage.DedupKey(p)}}}}
+		_, err = collection.UpdateOne(ctx, id, update)
+		if mongo.IsDuplicateKeyError(err) {
+			_, err = collection.DeleteOne(ctx, id)
+			removed++
+		} else {
+			updated++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err = res.Err(); err != nil {
+		return err
+	}
+
+	if updated > 0 || removed > 0 {
+		slog.Info("posts migrated to dedup key", slog.Int("updated", updated), slog.Int("removed", removed))
+	}
+	return nil
+}
+
+// migrateClusters отмечает сюжеты постов, записанных раньше. Пост
+// без сюжета начинает свой сюжет, а пост с сюжетом считается первым
+// постом сюжета, если сюжет совпадает с его ID. Повторный запуск
+// миграции ничего не меняет.
+func migrateClusters(ctx context.Context, collection *mongo.Collection) error {
+	id := bson.D{{Key: "$toString", Value: "$_id"}}
+	filter := bson.D{{Key: "clusterHead", Value: bson.D{{Key: "$exists", Value: false}}}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "cluster", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$cluster", id}}}},
+		}}},
+		{{Key: "$set", Value: bson.D{
+			{Key: "clusterHead", Value: bson.D{{Key: "$eq", Value: bson.A{"$cluster", id}}}},
+		}}},
+	}
+	res, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.ModifiedCount > 0 {
+		slog.Info("posts migrated to clusters", slog.Int64("updated", res.ModifiedCount))
+	}
+	return nil
+}
+
+// migrateMinHash вычисляет сигнатуры minhash.Signature постов,
+// записанных до появления сюжетов. Без сигнатуры такие посты
+// не находятся при поиске сюжета новых постов. Сигнатура вычисляется
+// по записанным заголовку и содержанию. Повторный запуск миграции
+// ничего не меняет.
+func migrateMinHash(ctx context.Context, collection *mongo.Collection) error {
+	filter := bson.D{{Key: "minHash", Value: bson.D{{Key: "$exists", Value: false}}}}
+	opts := options.Find().SetProjection(bson.D{
+		{Key: "title", Value: 1},
+		{Key: "content", Value: 1},
+	})
+	res, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer res.Close(ctx)
+
+	var updated int
+	for res.Next(ctx) {
+		var p storage.Post
+		err = res.Decode(&p)
+		if err != nil {
+			return err
+		}
+
+		obj, err := primitive.ObjectIDFromHex(p.ID)
+		if err != nil {
+			return err
+		}
+		sig := minhash.Signature(p.Title, p.Content)
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "minHash", Value: sig},
+			{Key: "minBands", Value: minhash.BandKeys(sig)},
+		}}}
+		_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: obj}}, update)
+		if err != nil {
+			return err
+		}
+		updated++
+	}
+	if err = res.Err(); err != nil {
+		return err
+	}
+
+	if updated > 0 {
+		slog.Info("posts migrated to minhash signatures", slog.Int("updated", updated))
+	}
+	return nil
+}
+
+// adoptLegacy привязывает к источникам посты, записанные до появления
+// источников. У таких постов нет sourceId, поэтому уникальный индекс
+// по источнику и ключу повтора не находит их повторы. Записанный пост
+// без источника считается повтором поста из posts с тем же ключом
+// повтора или ключом ссылки storage.LinkKey. Заголовки не сравниваются,
+// так как одинаковые заголовки бывают у разных постов. Такой пост
+// получает источник и ключ повтора нового поста, и новый пост при
+// записи обрабатывается как повтор. Если у источника уже есть пост
+// с этим ключом, пост без источника удаляется.
+func adoptLegacy(ctx context.Context, collection *mongo.Collection, posts []storage.Post) error {
+	keys, links := []string{}, []string{}
+	for _, p := range posts {
+		keys = append(keys, storage.DedupKey(p))
+		if p.Link != "" {
+			keys = append(keys, storage.LinkKey(p.Link))
+			links = append(links, p.Link)
+		}
+	}
+
+	// Условие на dedupKey позволяет использовать частичный индекс
+	// по источнику и ключу повтора.
+	filter := bson.D{
+		{Key: "sourceId", Value: nil},
+		{Key: "dedupKey", Value: bson.D{{Key: "$exists", Value: true}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "dedupKey", Value: bson.D{{Key: "$in", Value: keys}}}},
+			bson.D{{Key: "link", Value: bson.D{{Key: "$in", Value: links}}}},
+		}},
+	}
+	res, err := collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var legacy []storage.Post
+	err = res.All(ctx, &legacy)
+	if err != nil || len(legacy) == 0 {
+		return err
+	}
+
+	adopted := make(map[int]bool)
+	for _, old := range legacy {
+		i := legacyMatch(old, posts, adopted)
+		if i < 0 {
+			continue
+		}
+		adopted[i] = true
+
+		obj, err := primitive.ObjectIDFromHex(old.ID)
+		if err != nil {
+			return err
+		}
+		id := bson.D{{Key: "_id", Value: obj}}
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "sourceId", Value: posts[i].SourceID},
+			{Key: "dedupKey", Value: storage.DedupKey(posts[i])},
+			{Key: "guid", Value: posts[i].GUID},
+		}}}
+		_, err = collection.UpdateOne(ctx, id, update)
+		if mongo.IsDuplicateKeyError(err) {
+			_, err = collection.DeleteOne(ctx, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyMatch возвращает индекс поста из posts, повтором которого
+// является пост без источника old, или -1. Посты из adopted уже
+// сопоставлены другим постам без источника.
+func legacyMatch(old storage.Post, posts []storage.Post, adopted map[int]bool) int {
+	key := storage.DedupKey(old)
+	for i, p := range posts {
+		if adopted[i] {
+			continue
+		}
+		if key == storage.DedupKey(p) || (p.Link != "" && storage.LinkKey(old.Link) == storage.LinkKey(p.Link)) {
+			return i
+		}
+	}
+	return -1
+}
+
 // AddPosts читает посты из переданного канала и записывает
-// их в БД. Возвращает количество успешно записанных постов
-// и ошибку, отличную от duplicate key error.
+// их в БД. Пост с ключом повтора storage.DedupKey, уже записанным
+// для того же источника, не добавляется заново: если его заголовок
+// или содержание изменились, записанный пост обновляется, а прежняя
+// версия сохраняется в истории изменений. Новый пост относится
+// к сюжету похожего поста другого источника или начинает новый
+// сюжет. Возвращает количество добавленных постов и ошибку,
+// отличную от duplicate key error.
 func (s *Storage) AddPosts(ctx context.Context, posts <-chan storage.Post) (int, error) {
 	const operation = "storage.mongodb.AddPosts"
 
 	var input []interface{}
+	var list []storage.Post
+	var ids []primitive.ObjectID
 	for p := range posts {
+		id := primitive.NewObjectID()
 		bsn := bson.D{
-			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "_id", Value: id},
+			{Key: "sourceId", Value: p.SourceID},
 			{Key: "title", Value: p.Title},
 			{Key: "content", Value: p.Content},
+			{Key: "contentHtml", Value: p.ContentHTML},
 			{Key: "pubTime", Value: primitive.NewDateTimeFromTime(p.PubTime)},
+			{Key: "pubTimeGuessed", Value: p.PubTimeGuessed},
 			{Key: "link", Value: p.Link},
+			{Key: "guid", Value: p.GUID},
+			{Key: "author", Value: p.Author},
+			{Key: "categories", Value: p.Categories},
+			{Key: "enclosures", Value: p.Enclosures},
+			{Key: "image", Value: p.Image},
+			{Key: "lang", Value: p.Lang},
+			{Key: "tags", Value: p.Tags},
+			{Key: "fullText", Value: p.FullText},
+			{Key: "teaser", Value: p.Teaser},
+			{Key: "minHash", Value: p.MinHash},
+			{Key: "minBands", Value: minhash.BandKeys(p.MinHash)},
+			{Key: "cluster", Value: id.Hex()},
+			{Key: "clusterHead", Value: true},
+			{Key: "dedupKey", Value: storage.DedupKey(p)},
 		}
 		input = append(input, bsn)
+		list = append(list, p)
+		ids = append(ids, id)
+	}
+	if len(input) == 0 {
+		return 0, nil
 	}
 
 	collection := s.db.Database(dbName).Collection(colName)
+	err := adoptLegacy(ctx, collection, list)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", operation, err)
+	}
+
 	opts := options.InsertMany().SetOrdered(false)
 	res, err := collection.InsertMany(ctx, input, opts)
-	if err != nil && !mongo.IsDuplicateKeyError(err) {
-		return len(res.InsertedIDs), fmt.Errorf("%s: %w", operation, err)
+	var num int
+	if res != nil {
+		num = len(res.InsertedIDs)
+	}
+
+	// Повторы уже записанных постов проверяются на изменения.
+	var bulk mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bulk) {
+		return num, fmt.Errorf("%s: %w", operation, err)
+	}
+	failed := make(map[int]bool, len(bulk.WriteErrors))
+	for _, we := range bulk.WriteErrors {
+		if !mongo.IsDuplicateKeyError(we) {
+			return num, fmt.Errorf("%s: %w", operation, we)
+		}
+		failed[we.Index] = true
+		err = s.updatePost(ctx, list[we.Index])
+		if err != nil {
+			return num, fmt.Errorf("%s: %w", operation, err)
+		}
 	}
 
-	return len(res.InsertedIDs), nil
+	// Сюжет ищется только для добавленных постов, повторы сохраняют
+	// записанный сюжет. Пока сюжет не найден, пост начинает свой.
+	for i, p := range list {
+		if failed[i] {
+			continue
+		}
+		cluster, err := s.cluster(ctx, p, ids[i].Hex())
+		if err != nil {
+			return num, fmt.Errorf("%s: %w", operation, err)
+		}
+		if cluster == ids[i].Hex() {
+			continue
+		}
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "cluster", Value: cluster},
+			{Key: "clusterHead", Value: false},
+		}}}
+		_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: ids[i]}}, update)
+		if err != nil {
+			return num, fmt.Errorf("%s: %w", operation, err)
+		}
+	}
+
+	return num, nil
+}
+
+// cluster возвращает сюжет нового поста с переданным ID: сюжет
+// первого похожего поста другого источника, найденного по частям
+// сигнатуры minhash.BandKeys, или ID самого поста, если такого поста
+// нет.
+func (s *Storage) cluster(ctx context.Context, p storage.Post, id string) (string, error) {
+	bands := minhash.BandKeys(p.MinHash)
+	if len(bands) == 0 {
+		return id, nil
+	}
+
+	collection := s.db.Database(dbName).Collection(colName)
+	filter := bson.D{
+		{Key: "minBands", Value: bson.D{{Key: "$in", Value: bands}}},
+		{Key: "pubTime", Value: bson.D{
+			{Key: "$gte", Value: primitive.NewDateTimeFromTime(p.PubTime.Add(-storage.ClusterWindow))},
+			{Key: "$lte", Value: primitive.NewDateTimeFromTime(p.PubTime.Add(storage.ClusterWindow))},
+		}},
+		{Key: "sourceId", Value: bson.D{{Key: "$ne", Value: p.SourceID}}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "pubTime", Value: 1}}).
+		SetLimit(maxCandidates).
+		SetProjection(bson.D{
+			{Key: "sourceId", Value: 1},
+			{Key: "pubTime", Value: 1},
+			{Key: "minHash", Value: 1},
+			{Key: "cluster", Value: 1},
+		})
+	res, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []storage.Post
+	err = res.All(ctx, &candidates)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range candidates {
+		if !storage.SameStory(p, c) {
+			continue
+		}
+		if c.Cluster != "" {
+			return c.Cluster, nil
+		}
+		return c.ID, nil
+	}
+	return id, nil
+}
+
+// updatePost обновляет записанный пост с тем же источником и ключом
+// повтора, что у переданного, если хеш заголовка и содержания
+// storage.ContentHash отличается. Прежняя версия записывается
+// в коллекцию версий. Время публикации поста не меняется. Полный
+// текст статьи не заменяется описанием из ленты, если загрузить
+// статью заново не удалось. Если изменился только хеш поста в ленте
+// storage.Post.Teaser, он обновляется без записи версии.
+func (s *Storage) updatePost(ctx context.Context, p storage.Post) error {
+	collection := s.db.Database(dbName).Collection(colName)
+	filter := bson.D{
+		{Key: "sourceId", Value: p.SourceID},
+		{Key: "dedupKey", Value: storage.DedupKey(p)},
+	}
+	var old storage.Post
+	err := collection.FindOne(ctx, filter).Decode(&old)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p = storage.KeepFullText(old, p)
+
+	obj, err := primitive.ObjectIDFromHex(old.ID)
+	if err != nil {
+		return err
+	}
+	if storage.ContentHash(old) == storage.ContentHash(p) {
+		if old.Teaser == p.Teaser {
+			return nil
+		}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "teaser", Value: p.Teaser}}}}
+		_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: obj}}, update)
+		return err
+	}
+	now := time.Now()
+	created := old.Updated
+	if created.IsZero() {
+		created = old.PubTime
+	}
+
+	revisions := s.db.Database(dbName).Collection(revColName)
+	rev := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "postId", Value: old.ID},
+		{Key: "title", Value: old.Title},
+		{Key: "content", Value: old.Content},
+		{Key: "link", Value: old.Link},
+		{Key: "created", Value: primitive.NewDateTimeFromTime(created)},
+		{Key: "replaced", Value: primitive.NewDateTimeFromTime(now)},
+	}
+	_, err = revisions.InsertOne(ctx, rev)
+	if err != nil {
+		return err
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "title", Value: p.Title},
+		{Key: "content", Value: p.Content},
+		{Key: "contentHtml", Value: p.ContentHTML},
+		{Key: "link", Value: p.Link},
+		{Key: "author", Value: p.Author},
+		{Key: "categories", Value: p.Categories},
+		{Key: "enclosures", Value: p.Enclosures},
+		{Key: "image", Value: p.Image},
+		{Key: "lang", Value: p.Lang},
+		{Key: "tags", Value: p.Tags},
+		{Key: "fullText", Value: p.FullText},
+		{Key: "teaser", Value: p.Teaser},
+		{Key: "minHash", Value: p.MinHash},
+		{Key: "minBands", Value: minhash.BandKeys(p.MinHash)},
+		{Key: "updated", Value: primitive.NewDateTimeFromTime(now)},
+	}}}
+	_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: obj}}, update)
+	return err
+}
+
+// Revisions возвращает прежние версии поста с переданным ID, начиная
+// с последней. Если пост не менялся, возвращает storage.ErrNotFound.
+func (s *Storage) Revisions(ctx context.Context, postID string) ([]storage.Revision, error) {
+	const operation = "storage.mongodb.Revisions"
+
+	if _, err := primitive.ObjectIDFromHex(postID); err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, storage.ErrIncorrectId)
+	}
+
+	collection := s.db.Database(dbName).Collection(revColName)
+	filter := bson.D{{Key: "postId", Value: postID}}
+	opts := options.Find().SetSort(bson.D{{Key: "replaced", Value: -1}})
+	res, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	var revs []storage.Revision
+	err = res.All(ctx, &revs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+	if len(revs) == 0 {
+		return nil, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+	return revs, nil
 }
 
 // Posts возвращает посты из БД в соответствии с переданными опциями.
-// Опции включают в себя лимит числа постов, оффсет для пагинации и
-// запрос на текстовый поиск в заголовках.
+// Опции включают в себя лимит числа постов, оффсет для пагинации,
+// запрос на текстовый поиск в заголовках и фильтры по источнику,
+// языку и тегу.
 // Если параметр опции nil, то вернет все посты, отсортированные по
-// дате публикации.
+// дате публикации. При свертке сюжетов возвращаются только первые
+// посты сюжетов, а остальные посты сюжетов перечисляются
+// в AlsoCoveredBy.
 func (s *Storage) Posts(ctx context.Context, op ...*storage.Options) ([]storage.Post, error) {
 	const operation = "storage.mongodb.Posts"
 
-	filter := bson.D{}
+	filter := filterOpts(op...)
 	sort := bson.D{{Key: "pubTime", Value: -1}}
 	opts := options.Find()
 
 	var query string
 	var lim, off int64
-	if op[0] != nil {
+	if len(op) > 0 && op[0] != nil {
 		query = op[0].SearchQuery
 		lim = int64(op[0].Count)
 		off = int64(op[0].Offset)
 	}
 
 	if query != "" {
-		filter = bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: query}}}}
 		sort = bson.D{{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}}}
 	}
 	opts = opts.SetSort(sort)
@@ -178,18 +753,75 @@ func (s *Storage) Posts(ctx context.Context, op ...*storage.Options) ([]storage.
 		return nil, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
 	}
 
+	if len(op) > 0 && op[0] != nil && op[0].Collapse {
+		err = s.coverage(ctx, posts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", operation, err)
+		}
+	}
+
 	return posts, nil
 }
 
+// coverage заполняет AlsoCoveredBy первых постов сюжетов остальными
+// постами их сюжетов, начиная с самого раннего.
+func (s *Storage) coverage(ctx context.Context, heads []storage.Post) error {
+	clusters := make([]string, 0, len(heads))
+	for _, p := range heads {
+		clusters = append(clusters, p.Cluster)
+	}
+
+	collection := s.db.Database(dbName).Collection(colName)
+	filter := bson.D{
+		{Key: "cluster", Value: bson.D{{Key: "$in", Value: clusters}}},
+		{Key: "clusterHead", Value: false},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "pubTime", Value: 1}}).
+		SetProjection(bson.D{
+			{Key: "sourceId", Value: 1},
+			{Key: "title", Value: 1},
+			{Key: "link", Value: 1},
+			{Key: "pubTime", Value: 1},
+			{Key: "cluster", Value: 1},
+		})
+	res, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+
+	var members []storage.Post
+	err = res.All(ctx, &members)
+	if err != nil {
+		return err
+	}
+
+	also := make(map[string][]storage.Coverage)
+	for _, m := range members {
+		also[m.Cluster] = append(also[m.Cluster], storage.Coverage{
+			ID:       m.ID,
+			SourceID: m.SourceID,
+			Title:    m.Title,
+			Link:     m.Link,
+			PubTime:  m.PubTime,
+		})
+	}
+	for i := range heads {
+		heads[i].AlsoCoveredBy = also[heads[i].Cluster]
+	}
+	return nil
+}
+
 // Count возвращает число постов, соответствующих условиям поиска.
 func (s *Storage) Count(ctx context.Context, op ...*storage.Options) (int64, error) {
 	const operation = "storage.mongodb.Count"
 
-	filter := bson.D{}
+	filter := filterOpts(op...)
 	opts := options.Count().SetHint("_id_")
 
-	if op[0] != nil && op[0].SearchQuery != "" {
-		filter = bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: op[0].SearchQuery}}}}
+	// Подсказка индекса нужна только для подсчета всех постов без
+	// условий, с текстовым поиском ее использовать нельзя.
+	if len(filter) > 0 {
 		opts = nil
 	}
 
@@ -201,6 +833,31 @@ func (s *Storage) Count(ctx context.Context, op ...*storage.Options) (int64, err
 	return res, nil
 }
 
+// filterOpts формирует фильтр запроса постов из переданных опций.
+func filterOpts(op ...*storage.Options) bson.D {
+	filter := bson.D{}
+	if len(op) == 0 || op[0] == nil {
+		return filter
+	}
+
+	if op[0].SearchQuery != "" {
+		filter = append(filter, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: op[0].SearchQuery}}})
+	}
+	if op[0].SourceID != "" {
+		filter = append(filter, bson.E{Key: "sourceId", Value: op[0].SourceID})
+	}
+	if op[0].Lang != "" {
+		filter = append(filter, bson.E{Key: "lang", Value: op[0].Lang})
+	}
+	if op[0].Tag != "" {
+		filter = append(filter, bson.E{Key: "tags", Value: op[0].Tag})
+	}
+	if op[0].Collapse {
+		filter = append(filter, bson.E{Key: "clusterHead", Value: true})
+	}
+	return filter
+}
+
 // PostById возвращает пост по переданному ID.
 func (s *Storage) PostById(ctx context.Context, id string) (storage.Post, error) {
 	const operation = "storage.mongodb.PostById"
@@ -232,3 +889,473 @@ func (s *Storage) PostById(ctx context.Context, id string) (storage.Post, error)
 
 	return post, nil
 }
+
+// PostByKey возвращает пост источника sourceID с ключом повтора
+// storage.DedupKey key.
+func (s *Storage) PostByKey(ctx context.Context, sourceID, key string) (storage.Post, error) {
+	const operation = "storage.mongodb.PostByKey"
+	var post storage.Post
+
+	collection := s.db.Database(dbName).Collection(colName)
+	filter := bson.D{
+		{Key: "sourceId", Value: sourceID},
+		{Key: "dedupKey", Value: key},
+	}
+	res := collection.FindOne(ctx, filter)
+	if res.Err() == mongo.ErrNoDocuments {
+		return post, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+	if res.Err() != nil {
+		return post, fmt.Errorf("%s: %w", operation, res.Err())
+	}
+
+	err := res.Decode(&post)
+	if err != nil {
+		return post, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return post, nil
+}
+
+// AddSource добавляет источник в БД или обновляет метаданные уже
+// существующего источника с тем же адресом ленты. Возвращает ID
+// источника.
+func (s *Storage) AddSource(ctx context.Context, src storage.Source) (string, error) {
+	const operation = "storage.mongodb.AddSource"
+
+	collection := s.db.Database(dbName).Collection(srcColName)
+	filter := bson.D{{Key: "url", Value: src.URL}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "title", Value: src.Title},
+			{Key: "link", Value: src.Link},
+			{Key: "description", Value: src.Description},
+			{Key: "language", Value: src.Language},
+			{Key: "image", Value: src.Image},
+			{Key: "updated", Value: primitive.NewDateTimeFromTime(time.Now())},
+		}},
+		{Key: "$setOnInsert", Value: bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+		}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var res storage.Source
+	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&res)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return res.ID, nil
+}
+
+// Sources возвращает все источники из БД, отсортированные по названию.
+func (s *Storage) Sources(ctx context.Context) ([]storage.Source, error) {
+	const operation = "storage.mongodb.Sources"
+
+	collection := s.db.Database(dbName).Collection(srcColName)
+	opts := options.Find().SetSort(bson.D{{Key: "title", Value: 1}})
+	res, err := collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	var sources []storage.Source
+	err = res.All(ctx, &sources)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+
+	return sources, nil
+}
+
+// Tags возвращает теги постов с количеством постов по каждому тегу,
+// отсортированные по убыванию количества, а при равном количестве -
+// по названию тега. Если у постов нет тегов, возвращает
+// storage.ErrNotFound.
+func (s *Storage) Tags(ctx context.Context) ([]storage.TagCount, error) {
+	const operation = "storage.mongodb.Tags"
+
+	collection := s.db.Database(dbName).Collection(colName)
+	pipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$tags"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	res, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	var tags []storage.TagCount
+	err = res.All(ctx, &tags)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+
+	return tags, nil
+}
+
+// FeedState возвращает состояние опроса ленты с переданным адресом.
+// Если лента еще не опрашивалась, возвращает storage.ErrNotFound.
+func (s *Storage) FeedState(ctx context.Context, url string) (storage.FeedState, error) {
+	const operation = "storage.mongodb.FeedState"
+
+	collection := s.db.Database(dbName).Collection(stateColName)
+	filter := bson.D{{Key: "_id", Value: url}}
+
+	var st storage.FeedState
+	res := collection.FindOne(ctx, filter)
+	if res.Err() == mongo.ErrNoDocuments {
+		return st, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+	if res.Err() != nil {
+		return st, fmt.Errorf("%s: %w", operation, res.Err())
+	}
+
+	err := res.Decode(&st)
+	if err != nil {
+		return st, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return st, nil
+}
+
+// SetFeedState записывает состояние опроса ленты, заменяя предыдущее.
+func (s *Storage) SetFeedState(ctx context.Context, st storage.FeedState) error {
+	const operation = "storage.mongodb.SetFeedState"
+
+	collection := s.db.Database(dbName).Collection(stateColName)
+	filter := bson.D{{Key: "_id", Value: st.URL}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "etag", Value: st.ETag},
+			{Key: "lastModified", Value: st.LastModified},
+			{Key: "health", Value: st.Health},
+			{Key: "failures", Value: st.Failures},
+			{Key: "lastError", Value: st.LastError},
+			{Key: "lastSuccess", Value: primitive.NewDateTimeFromTime(st.LastSuccess)},
+			{Key: "lastFailure", Value: primitive.NewDateTimeFromTime(st.LastFailure)},
+			{Key: "nextAttempt", Value: primitive.NewDateTimeFromTime(st.NextAttempt)},
+			{Key: "notModified", Value: st.NotModified},
+			{Key: "hub", Value: st.Hub},
+			{Key: "topic", Value: st.Topic},
+			{Key: "updated", Value: primitive.NewDateTimeFromTime(time.Now())},
+		}},
+	}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return nil
+}
+
+// FeedStates возвращает состояния опроса всех лент, отсортированные
+// по адресу.
+func (s *Storage) FeedStates(ctx context.Context) ([]storage.FeedState, error) {
+	const operation = "storage.mongodb.FeedStates"
+
+	collection := s.db.Database(dbName).Collection(stateColName)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	res, err := collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	var states []storage.FeedState
+	err = res.All(ctx, &states)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	if len(states) == 0 {
+		return nil, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+
+	return states, nil
+}
+
+// Feeds возвращает настройки всех RSS лент, отсортированные по адресу.
+func (s *Storage) Feeds(ctx context.Context) ([]storage.Feed, error) {
+	const operation = "storage.mongodb.Feeds"
+
+	collection := s.db.Database(dbName).Collection(feedColName)
+	opts := options.Find().SetSort(bson.D{{Key: "url", Value: 1}})
+	res, err := collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	var feeds []storage.Feed
+	err = res.All(ctx, &feeds)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	if len(feeds) == 0 {
+		return nil, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+
+	return feeds, nil
+}
+
+// seededID - ID документа служебной коллекции, отмечающего, что список
+// лент уже заполнен лентами из файла конфига.
+const seededID = "feedsSeeded"
+
+// FeedsSeeded сообщает, заполнялся ли список RSS лент лентами из файла
+// конфига.
+func (s *Storage) FeedsSeeded(ctx context.Context) (bool, error) {
+	const operation = "storage.mongodb.FeedsSeeded"
+
+	collection := s.db.Database(dbName).Collection(metaColName)
+	filter := bson.D{{Key: "_id", Value: seededID}}
+	err := collection.FindOne(ctx, filter).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return true, nil
+}
+
+// SetFeedsSeeded отмечает, что список RSS лент заполнен лентами
+// из файла конфига. После этого список не заполняется повторно, даже
+// если из него удалены все ленты.
+func (s *Storage) SetFeedsSeeded(ctx context.Context) error {
+	const operation = "storage.mongodb.SetFeedsSeeded"
+
+	collection := s.db.Database(dbName).Collection(metaColName)
+	filter := bson.D{{Key: "_id", Value: seededID}}
+	update := bson.D{
+		{Key: "$setOnInsert", Value: bson.D{
+			{Key: "time", Value: primitive.NewDateTimeFromTime(time.Now())},
+		}},
+	}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return nil
+}
+
+// FeedById возвращает настройки RSS ленты по ее ID.
+func (s *Storage) FeedById(ctx context.Context, id string) (storage.Feed, error) {
+	const operation = "storage.mongodb.FeedById"
+	var feed storage.Feed
+
+	obj, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return feed, fmt.Errorf("%s: %w", operation, storage.ErrIncorrectId)
+	}
+
+	collection := s.db.Database(dbName).Collection(feedColName)
+	filter := bson.D{{Key: "_id", Value: obj}}
+	res := collection.FindOne(ctx, filter)
+	if res.Err() == mongo.ErrNoDocuments {
+		return feed, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+	if res.Err() != nil {
+		return feed, fmt.Errorf("%s: %w", operation, res.Err())
+	}
+
+	err = res.Decode(&feed)
+	if err != nil {
+		return feed, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return feed, nil
+}
+
+// AddFeed записывает настройки новой RSS ленты и возвращает ее ID.
+// Если лента с таким адресом уже есть, возвращает storage.ErrDuplicate.
+func (s *Storage) AddFeed(ctx context.Context, feed storage.Feed) (string, error) {
+	const operation = "storage.mongodb.AddFeed"
+
+	id := primitive.NewObjectID()
+	bsn := bson.D{{Key: "_id", Value: id}}
+	bsn = append(bsn, feedFields(feed)...)
+
+	collection := s.db.Database(dbName).Collection(feedColName)
+	_, err := collection.InsertOne(ctx, bsn)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", fmt.Errorf("%s: %w", operation, storage.ErrDuplicate)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return id.Hex(), nil
+}
+
+// UpdateFeed заменяет настройки RSS ленты с ID из переданной структуры.
+// При изменении адреса ленты удаляются состояние опроса прежнего адреса
+// и подписка WebSub ленты.
+func (s *Storage) UpdateFeed(ctx context.Context, feed storage.Feed) error {
+	const operation = "storage.mongodb.UpdateFeed"
+
+	obj, err := primitive.ObjectIDFromHex(feed.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, storage.ErrIncorrectId)
+	}
+
+	collection := s.db.Database(dbName).Collection(feedColName)
+	filter := bson.D{{Key: "_id", Value: obj}}
+	update := bson.D{{Key: "$set", Value: feedFields(feed)}}
+	var old storage.Feed
+	err = collection.FindOneAndUpdate(ctx, filter, update).Decode(&old)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%s: %w", operation, storage.ErrDuplicate)
+	}
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	if old.URL == feed.URL {
+		return nil
+	}
+
+	// Состояние опроса и подписка WebSub относятся к прежнему адресу
+	// ленты: ETag, отсрочка после ошибок и хаб для нового адреса
+	// неизвестны.
+	states := s.db.Database(dbName).Collection(stateColName)
+	_, err = states.DeleteOne(ctx, bson.D{{Key: "_id", Value: old.URL}})
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	subs := s.db.Database(dbName).Collection(subColName)
+	_, err = subs.DeleteOne(ctx, bson.D{{Key: "_id", Value: feed.ID}})
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return nil
+}
+
+// RemoveFeed удаляет настройки RSS ленты вместе с состоянием ее опроса.
+// Источник и посты ленты остаются в БД.
+func (s *Storage) RemoveFeed(ctx context.Context, id string) error {
+	const operation = "storage.mongodb.RemoveFeed"
+
+	obj, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, storage.ErrIncorrectId)
+	}
+
+	collection := s.db.Database(dbName).Collection(feedColName)
+	filter := bson.D{{Key: "_id", Value: obj}}
+	var feed storage.Feed
+	err = collection.FindOneAndDelete(ctx, filter).Decode(&feed)
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	states := s.db.Database(dbName).Collection(stateColName)
+	_, err = states.DeleteOne(ctx, bson.D{{Key: "_id", Value: feed.URL}})
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	subs := s.db.Database(dbName).Collection(subColName)
+	_, err = subs.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return nil
+}
+
+// Subscription возвращает подписку WebSub на ленту с переданным ID.
+// Если подписки нет, возвращает storage.ErrNotFound.
+func (s *Storage) Subscription(ctx context.Context, feedID string) (storage.Subscription, error) {
+	const operation = "storage.mongodb.Subscription"
+
+	collection := s.db.Database(dbName).Collection(subColName)
+	filter := bson.D{{Key: "_id", Value: feedID}}
+
+	var sub storage.Subscription
+	res := collection.FindOne(ctx, filter)
+	if res.Err() == mongo.ErrNoDocuments {
+		return sub, fmt.Errorf("%s: %w", operation, storage.ErrNotFound)
+	}
+	if res.Err() != nil {
+		return sub, fmt.Errorf("%s: %w", operation, res.Err())
+	}
+
+	err := res.Decode(&sub)
+	if err != nil {
+		return sub, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return sub, nil
+}
+
+// SetSubscription записывает подписку WebSub на ленту, заменяя
+// предыдущую подписку на ту же ленту.
+func (s *Storage) SetSubscription(ctx context.Context, sub storage.Subscription) error {
+	const operation = "storage.mongodb.SetSubscription"
+
+	collection := s.db.Database(dbName).Collection(subColName)
+	filter := bson.D{{Key: "_id", Value: sub.FeedID}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "hub", Value: sub.Hub},
+			{Key: "topic", Value: sub.Topic},
+			{Key: "secret", Value: sub.Secret},
+			{Key: "state", Value: sub.State},
+			{Key: "leaseSeconds", Value: sub.LeaseSeconds},
+			{Key: "leaseExpires", Value: primitive.NewDateTimeFromTime(sub.LeaseExpires)},
+			{Key: "requested", Value: primitive.NewDateTimeFromTime(sub.Requested)},
+			{Key: "lastDelivery", Value: primitive.NewDateTimeFromTime(sub.LastDelivery)},
+			{Key: "updated", Value: primitive.NewDateTimeFromTime(time.Now())},
+		}},
+	}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	return nil
+}
+
+// feedFields возвращает поля документа с настройками RSS ленты.
+func feedFields(feed storage.Feed) bson.D {
+	return bson.D{
+		{Key: "url", Value: feed.URL},
+		{Key: "name", Value: feed.Name},
+		{Key: "group", Value: feed.Group},
+		{Key: "fullText", Value: feed.FullText},
+		{Key: "period", Value: feed.Period},
+		{Key: "timeout", Value: feed.Timeout},
+		{Key: "enabled", Value: feed.Enabled},
+		{Key: "headers", Value: feed.Headers},
+		{Key: "auth", Value: feed.Auth},
+	}
+}
@@ -3,11 +3,14 @@
 package mongodb
 
 import (
+	"GoNews/internal/minhash"
 	"GoNews/internal/storage"
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -21,22 +24,30 @@ import (
 var path string = "mongodb://192.168.0.102:27017/"
 var posts = []storage.Post{
 	{
-		Title:   fmt.Sprintf("Test post one %d", rand.Int()),
-		Content: "Test content 1",
-		Link:    "https://google.com",
-		PubTime: time.Now(),
+		SourceID: "1",
+		Title:    fmt.Sprintf("Test post one %d", rand.Int()),
+		Content:  "Test content 1",
+		Link:     "https://google.com/1",
+		PubTime:  time.Now(),
+		Lang:     "en",
+		Tags:     []string{"generics", "go"},
 	},
 	{
-		Title:   fmt.Sprintf("Test post one %d", rand.Int()),
-		Content: "Test content 2",
-		Link:    "https://google.com",
-		PubTime: time.Now(),
+		SourceID: "1",
+		Title:    fmt.Sprintf("Test post one %d", rand.Int()),
+		Content:  "Test content 2",
+		Link:     "https://google.com/2",
+		PubTime:  time.Now(),
+		Lang:     "en",
+		Tags:     []string{"go"},
 	},
 	{
-		Title:   fmt.Sprintf("Test post two %d", rand.Int()),
-		Content: "Test content 3",
-		Link:    "https://google.com",
-		PubTime: time.Now(),
+		SourceID: "2",
+		Title:    fmt.Sprintf("Test post two %d", rand.Int()),
+		Content:  "Test content 3",
+		Link:     "https://google.com/3",
+		PubTime:  time.Now(),
+		Lang:     "ru",
 	},
 }
 
@@ -44,10 +55,17 @@ var posts = []storage.Post{
 func (s *Storage) addOne(p storage.Post) (string, error) {
 	bsn := bson.D{
 		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "sourceId", Value: p.SourceID},
 		{Key: "title", Value: p.Title},
 		{Key: "content", Value: p.Content},
 		{Key: "pubTime", Value: primitive.NewDateTimeFromTime(time.Now())},
 		{Key: "link", Value: p.Link},
+		{Key: "guid", Value: p.GUID},
+		{Key: "author", Value: p.Author},
+		{Key: "categories", Value: p.Categories},
+		{Key: "enclosures", Value: p.Enclosures},
+		{Key: "lang", Value: p.Lang},
+		{Key: "tags", Value: p.Tags},
 	}
 	collection := s.db.Database(dbName).Collection(colName)
 	res, err := collection.InsertOne(context.Background(), bsn)
@@ -121,6 +139,216 @@ func TestStorage_AddPosts(t *testing.T) {
 	}
 }
 
+// Test_migrateDedup проверяет заполнение ключей повтора у постов,
+// записанных без них, и удаление повторов.
+func Test_migrateDedup(t *testing.T) {
+
+	dbName = "testDB"
+	colName = "testMigrate"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+	err = st.trun()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Один и тот же пост с исправленным заголовком и пост с таким же
+	// заголовком из другого источника.
+	legacy := []storage.Post{
+		{SourceID: "1", Title: "Go Weekly", GUID: "1"},
+		{SourceID: "1", Title: "Go Weekly #1", GUID: "1"},
+		{SourceID: "2", Title: "Go Weekly", GUID: "1"},
+	}
+	for _, p := range legacy {
+		_, err = st.addOne(p)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	collection := st.db.Database(dbName).Collection(colName)
+	err = migrateDedup(context.Background(), collection)
+	if err != nil {
+		t.Fatalf("migrateDedup() error = %v", err)
+	}
+	got, err := st.Count(context.Background())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != 2 {
+		t.Errorf("migrateDedup() posts = %v, want %v", got, 2)
+	}
+
+	// После миграции повтор не записывается.
+	ch := make(chan storage.Post, 1)
+	ch <- storage.Post{SourceID: "1", Title: "Go Weekly #2", GUID: "1"}
+	close(ch)
+	n, err := st.AddPosts(context.Background(), ch)
+	if err != nil || n != 0 {
+		t.Errorf("Storage.AddPosts() = %v, %v, want 0", n, err)
+	}
+}
+
+// Test_migrateMinHash проверяет, что посты, записанные до появления
+// сюжетов, после миграции находятся при поиске сюжета новых постов.
+func Test_migrateMinHash(t *testing.T) {
+
+	dbName = "testDB"
+	colName = "testMigrate"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+	err = st.trun()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	const title = "Go 1.23 is released with iterators and telemetry"
+	const content = "The Go team is happy to announce the release of Go 1.23 with range over func iterators, opt-in telemetry and many other changes."
+
+	// Пост в том виде, в котором его записывали версии сервиса
+	// без сюжетов.
+	collection := st.db.Database(dbName).Collection(colName)
+	obj := primitive.NewObjectID()
+	_, err = collection.InsertOne(context.Background(), bson.D{
+		{Key: "_id", Value: obj},
+		{Key: "sourceId", Value: "1"},
+		{Key: "title", Value: title},
+		{Key: "content", Value: content},
+		{Key: "pubTime", Value: primitive.NewDateTimeFromTime(time.Now())},
+		{Key: "dedupKey", Value: "link:https://go.dev/blog/go1.23"},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		err = migrateMinHash(context.Background(), collection)
+		if err != nil {
+			t.Fatalf("migrateMinHash() error = %v", err)
+		}
+	}
+
+	var got bson.M
+	err = collection.FindOne(context.Background(), bson.D{{Key: "_id", Value: obj}}).Decode(&got)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, ok := got["minHash"]; !ok {
+		t.Errorf("migrateMinHash() minHash not set")
+	}
+
+	// Тот же пост другого источника относится к сюжету записанного.
+	ch := make(chan storage.Post, 1)
+	ch <- storage.Post{
+		SourceID: "2",
+		Title:    title,
+		Content:  content,
+		Link:     "https://example.com/go1.23",
+		PubTime:  time.Now(),
+		MinHash:  minhash.Signature(title, content),
+	}
+	close(ch)
+	n, err := st.AddPosts(context.Background(), ch)
+	if err != nil || n != 1 {
+		t.Fatalf("Storage.AddPosts() = %v, %v, want 1", n, err)
+	}
+
+	posts, err := st.Posts(context.Background(), &storage.Options{SourceID: "2"})
+	if err != nil || len(posts) != 1 {
+		t.Fatalf("Storage.Posts() = %v, %v, want one post", posts, err)
+	}
+	if posts[0].Cluster != obj.Hex() {
+		t.Errorf("Storage.AddPosts() cluster = %v, want %v", posts[0].Cluster, obj.Hex())
+	}
+}
+
+func Test_adoptLegacy(t *testing.T) {
+
+	dbName = "testDB"
+	colName = "testMigrate"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+	err = st.trun()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Посты в том виде, в котором их записывали версии сервиса без
+	// источников: без sourceId, guid и ключа повтора. Второй пост
+	// с тем же заголовком - другая статья.
+	collection := st.db.Database(dbName).Collection(colName)
+	_, err = collection.InsertMany(context.Background(), []interface{}{
+		bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "title", Value: "Go 1.23 is released"},
+			{Key: "content", Value: "Go 1.23 content"},
+			{Key: "pubTime", Value: primitive.NewDateTimeFromTime(time.Now())},
+			{Key: "link", Value: "https://go.dev/blog/go1.23/"},
+		},
+		bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "title", Value: "Go 1.23 is released"},
+			{Key: "content", Value: "Go 1.23 is out"},
+			{Key: "pubTime", Value: primitive.NewDateTimeFromTime(time.Now())},
+			{Key: "link", Value: "https://example.com/go1.23"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = migrateDedup(context.Background(), collection)
+	if err != nil {
+		t.Fatalf("migrateDedup() error = %v", err)
+	}
+
+	// Повторное получение поста с GUID и ссылкой без завершающего "/"
+	// не создает новый пост, записанный пост привязывается к источнику,
+	// а пост с тем же заголовком остается без источника.
+	for i := 0; i < 2; i++ {
+		ch := make(chan storage.Post, 1)
+		ch <- storage.Post{
+			SourceID: "1",
+			Title:    "Go 1.23 is released",
+			Content:  "Go 1.23 content",
+			Link:     "https://go.dev/blog/go1.23",
+			GUID:     "tag:go.dev,2024:go1.23",
+			PubTime:  time.Now(),
+		}
+		close(ch)
+		n, err := st.AddPosts(context.Background(), ch)
+		if err != nil || n != 0 {
+			t.Errorf("Storage.AddPosts() = %v, %v, want 0", n, err)
+		}
+	}
+
+	got, err := st.Count(context.Background())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != 2 {
+		t.Errorf("Storage.AddPosts() posts = %v, want %v", got, 2)
+	}
+	n, err := st.Count(context.Background(), &storage.Options{SourceID: "1"})
+	if err != nil || n != 1 {
+		t.Errorf("Storage.Count() source = %v, %v, want 1", n, err)
+	}
+}
+
 func TestStorage_Posts(t *testing.T) {
 
 	dbName = "testDB"
@@ -199,6 +427,36 @@ func TestStorage_Posts(t *testing.T) {
 			want:    3,
 			wantErr: false,
 		},
+		{
+			name:    "OK_Source",
+			opts:    &storage.Options{SourceID: "1"},
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name:    "OK_Source_and_search",
+			opts:    &storage.Options{SearchQuery: "two", SourceID: "1"},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "OK_Lang",
+			opts:    &storage.Options{Lang: "en"},
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name:    "OK_Lang_and_search",
+			opts:    &storage.Options{SearchQuery: "two", Lang: "en"},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "OK_Tag",
+			opts:    &storage.Options{Tag: "generics"},
+			want:    1,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -262,6 +520,24 @@ func TestStorage_Count(t *testing.T) {
 			want:    0,
 			wantErr: false,
 		},
+		{
+			name:    "Count_Source",
+			opts:    &storage.Options{SourceID: "2"},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "Count_Lang",
+			opts:    &storage.Options{Lang: "ru"},
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "Count_Tag",
+			opts:    &storage.Options{Tag: "go"},
+			want:    2,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -313,7 +589,7 @@ func TestStorage_PostById(t *testing.T) {
 		{
 			name:    "OK_One",
 			id:      ids[0],
-			want:    "https://google.com",
+			want:    "https://google.com/1",
 			wantErr: false,
 		},
 		{
@@ -348,3 +624,545 @@ func TestStorage_PostById(t *testing.T) {
 		})
 	}
 }
+
+func TestStorage_PostByKey(t *testing.T) {
+
+	dbName = "testDB"
+	colName = "testCollection"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	err = st.trun()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := storage.Post{SourceID: "1", Title: "Go 1.23", Content: "Полный текст", Link: "https://go.dev/blog/go1.23", FullText: true, Teaser: "teaser"}
+	ch := make(chan storage.Post, 1)
+	ch <- post
+	close(ch)
+	_, err = st.AddPosts(context.Background(), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.PostByKey(context.Background(), "1", storage.DedupKey(post))
+	if err != nil {
+		t.Fatalf("Storage.PostByKey() error = %v", err)
+	}
+	if got.Content != post.Content || !got.FullText || got.Teaser != post.Teaser {
+		t.Errorf("Storage.PostByKey() = %+v, want %+v", got, post)
+	}
+
+	// У другого источника поста с этим ключом нет.
+	_, err = st.PostByKey(context.Background(), "2", storage.DedupKey(post))
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.PostByKey() error = %v, want %v", err, storage.ErrNotFound)
+	}
+}
+
+func TestStorage_Sources(t *testing.T) {
+
+	dbName = "testDB"
+	srcColName = "testSources"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	collection := st.db.Database(dbName).Collection(srcColName)
+	_, err = collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := storage.Source{URL: "https://google.com/rss", Title: "Google"}
+	first, err := st.AddSource(context.Background(), src)
+	if err != nil {
+		t.Fatalf("Storage.AddSource() error = %v", err)
+	}
+
+	// Повторное добавление источника с тем же адресом должно обновить
+	// метаданные и вернуть тот же ID.
+	src.Title = "Google News"
+	second, err := st.AddSource(context.Background(), src)
+	if err != nil {
+		t.Fatalf("Storage.AddSource() error = %v", err)
+	}
+	if first != second {
+		t.Errorf("Storage.AddSource() = %v, want %v", second, first)
+	}
+
+	got, err := st.Sources(context.Background())
+	if err != nil {
+		t.Fatalf("Storage.Sources() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Title != src.Title {
+		t.Errorf("Storage.Sources() = %v, want one source titled %v", got, src.Title)
+	}
+}
+
+func TestStorage_FeedState(t *testing.T) {
+
+	dbName = "testDB"
+	stateColName = "testFeedStates"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	collection := st.db.Database(dbName).Collection(stateColName)
+	_, err = collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const url = "https://google.com/rss"
+	_, err = st.FeedState(context.Background(), url)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Storage.FeedState() error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	// Повторная запись состояния той же ленты должна заменить значения.
+	state := storage.FeedState{
+		URL:          url,
+		ETag:         `"v1"`,
+		LastModified: "Sat, 27 Jul 2024 00:00:00 GMT",
+		Health:       storage.HealthDegraded,
+		Failures:     1,
+		LastError:    "connection refused",
+	}
+	err = st.SetFeedState(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Storage.SetFeedState() error = %v", err)
+	}
+	state.ETag = `"v2"`
+	err = st.SetFeedState(context.Background(), state)
+	if err != nil {
+		t.Fatalf("Storage.SetFeedState() error = %v", err)
+	}
+
+	got, err := st.FeedState(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Storage.FeedState() error = %v", err)
+	}
+	if got.ETag != state.ETag || got.LastModified != state.LastModified ||
+		got.Health != state.Health || got.Failures != state.Failures || got.LastError != state.LastError {
+		t.Errorf("Storage.FeedState() = %+v, want %+v", got, state)
+	}
+
+	states, err := st.FeedStates(context.Background())
+	if err != nil {
+		t.Fatalf("Storage.FeedStates() error = %v", err)
+	}
+	if len(states) != 1 || states[0].URL != url {
+		t.Errorf("Storage.FeedStates() = %v, want one state with url %v", states, url)
+	}
+}
+
+func TestStorage_Feeds(t *testing.T) {
+
+	dbName = "testDB"
+	feedColName = "testFeeds"
+	stateColName = "testFeedStates"
+	subColName = "testSubscriptions"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	collection := st.db.Database(dbName).Collection(feedColName)
+	_, err = collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = st.Feeds(context.Background())
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Storage.Feeds() error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	feed := storage.Feed{URL: "https://google.com/rss", Period: time.Hour, Enabled: true}
+	id, err := st.AddFeed(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("Storage.AddFeed() error = %v", err)
+	}
+
+	// Повторное добавление ленты с тем же адресом запрещено.
+	_, err = st.AddFeed(context.Background(), feed)
+	if !errors.Is(err, storage.ErrDuplicate) {
+		t.Fatalf("Storage.AddFeed() error = %v, want %v", err, storage.ErrDuplicate)
+	}
+
+	feed.ID = id
+	feed.Name = "Google"
+	feed.Enabled = false
+	err = st.UpdateFeed(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("Storage.UpdateFeed() error = %v", err)
+	}
+
+	got, err := st.Feeds(context.Background())
+	if err != nil {
+		t.Fatalf("Storage.Feeds() error = %v", err)
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0], feed) {
+		t.Errorf("Storage.Feeds() = %+v, want %+v", got, feed)
+	}
+
+	byID, err := st.FeedById(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Storage.FeedById() error = %v", err)
+	}
+	if !reflect.DeepEqual(byID, feed) {
+		t.Errorf("Storage.FeedById() = %+v, want %+v", byID, feed)
+	}
+
+	// При изменении адреса состояние опроса прежнего адреса удаляется.
+	err = st.SetFeedState(context.Background(), storage.FeedState{URL: feed.URL, ETag: "1", Health: storage.HealthHealthy})
+	if err != nil {
+		t.Fatalf("Storage.SetFeedState() error = %v", err)
+	}
+	oldURL := feed.URL
+	feed.URL = "https://google.com/feed"
+	err = st.UpdateFeed(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("Storage.UpdateFeed() error = %v", err)
+	}
+	_, err = st.FeedState(context.Background(), oldURL)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.FeedState() error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	err = st.RemoveFeed(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Storage.RemoveFeed() error = %v", err)
+	}
+	err = st.RemoveFeed(context.Background(), id)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.RemoveFeed() error = %v, want %v", err, storage.ErrNotFound)
+	}
+}
+
+func TestStorage_FeedsSeeded(t *testing.T) {
+
+	dbName = "testDB"
+	metaColName = "testMeta"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	collection := st.db.Database(dbName).Collection(metaColName)
+	_, err = collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seeded, err := st.FeedsSeeded(context.Background())
+	if err != nil || seeded {
+		t.Fatalf("Storage.FeedsSeeded() = %v, %v, want false, nil", seeded, err)
+	}
+
+	// Повторная отметка не считается ошибкой.
+	for i := 0; i < 2; i++ {
+		err = st.SetFeedsSeeded(context.Background())
+		if err != nil {
+			t.Fatalf("Storage.SetFeedsSeeded() error = %v", err)
+		}
+	}
+
+	seeded, err = st.FeedsSeeded(context.Background())
+	if err != nil || !seeded {
+		t.Errorf("Storage.FeedsSeeded() = %v, %v, want true, nil", seeded, err)
+	}
+}
+
+func TestStorage_Subscription(t *testing.T) {
+
+	dbName = "testDB"
+	subColName = "testSubscriptions"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	collection := st.db.Database(dbName).Collection(subColName)
+	_, err = collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const id = "66a7cbb9b1f4ef8e2d1e6f3a"
+	_, err = st.Subscription(context.Background(), id)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Storage.Subscription() error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	// Подтверждение подписки хабом заменяет запрошенную подписку.
+	sub := storage.Subscription{
+		FeedID: id,
+		Hub:    "https://pubsubhubbub.appspot.com/",
+		Topic:  "https://go.dev/blog/feed.atom",
+		Secret: "secret",
+		State:  storage.SubscriptionPending,
+	}
+	err = st.SetSubscription(context.Background(), sub)
+	if err != nil {
+		t.Fatalf("Storage.SetSubscription() error = %v", err)
+	}
+	sub.State = storage.SubscriptionActive
+	sub.LeaseSeconds = 864000
+	err = st.SetSubscription(context.Background(), sub)
+	if err != nil {
+		t.Fatalf("Storage.SetSubscription() error = %v", err)
+	}
+
+	got, err := st.Subscription(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Storage.Subscription() error = %v", err)
+	}
+	if got.Hub != sub.Hub || got.Topic != sub.Topic || got.Secret != sub.Secret ||
+		got.State != sub.State || got.LeaseSeconds != sub.LeaseSeconds {
+		t.Errorf("Storage.Subscription() = %+v, want %+v", got, sub)
+	}
+}
+
+func TestStorage_Revisions(t *testing.T) {
+
+	dbName = "testDB"
+	colName = "testRevisions"
+	revColName = "testRevisionsHistory"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+	err = st.trun()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	add := func(p storage.Post) int {
+		ch := make(chan storage.Post, 1)
+		ch <- p
+		close(ch)
+		n, err := st.AddPosts(context.Background(), ch)
+		if err != nil {
+			t.Fatalf("Storage.AddPosts() error = %v", err)
+		}
+		return n
+	}
+
+	post := storage.Post{SourceID: "1", Title: "Go 1.23", Content: "Go 1.23 is relased", GUID: "go1.23", PubTime: time.Now()}
+	if n := add(post); n != 1 {
+		t.Fatalf("Storage.AddPosts() = %v, want 1", n)
+	}
+	// Тот же пост без изменений не создает версию.
+	add(post)
+	// Исправленный пост обновляет записанный.
+	fixed := post
+	fixed.Content = "Go 1.23 is released"
+	if n := add(fixed); n != 0 {
+		t.Errorf("Storage.AddPosts() = %v, want 0", n)
+	}
+
+	posts, err := st.Posts(context.Background())
+	if err != nil || len(posts) != 1 {
+		t.Fatalf("Storage.Posts() = %v, %v, want one post", posts, err)
+	}
+	if posts[0].Content != fixed.Content || posts[0].Updated.IsZero() {
+		t.Errorf("Storage.Posts() = %+v, want updated post", posts[0])
+	}
+
+	revs, err := st.Revisions(context.Background(), posts[0].ID)
+	if err != nil {
+		t.Fatalf("Storage.Revisions() error = %v", err)
+	}
+	if len(revs) != 1 || revs[0].Content != post.Content || revs[0].PostID != posts[0].ID {
+		t.Errorf("Storage.Revisions() = %+v, want previous version", revs)
+	}
+
+	_, err = st.Revisions(context.Background(), primitive.NewObjectID().Hex())
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.Revisions() error = %v, want %v", err, storage.ErrNotFound)
+	}
+	_, err = st.Revisions(context.Background(), "asdf")
+	if !errors.Is(err, storage.ErrIncorrectId) {
+		t.Errorf("Storage.Revisions() error = %v, want %v", err, storage.ErrIncorrectId)
+	}
+}
+
+func TestStorage_AddPosts_fullText(t *testing.T) {
+
+	dbName = "testDB"
+	colName = "testCollection"
+	revColName = "testRevisionsHistory"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	err = st.trun()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	add := func(p storage.Post) {
+		ch := make(chan storage.Post, 1)
+		ch <- p
+		close(ch)
+		_, err := st.AddPosts(context.Background(), ch)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Неудачная повторная загрузка статьи не заменяет записанный
+	// полный текст описанием из ленты и не создает версию.
+	add(storage.Post{SourceID: "1", Title: "Go 1.23", Content: "Полный текст", FullText: true, GUID: "go1.23", PubTime: time.Now()})
+	add(storage.Post{SourceID: "1", Title: "Go 1.23", Content: "Читать далее", FullTextFailed: true, GUID: "go1.23", PubTime: time.Now()})
+
+	posts, err := st.Posts(context.Background())
+	if err != nil || len(posts) != 1 {
+		t.Fatalf("Storage.Posts() = %v, %v, want 1 post", posts, err)
+	}
+	if posts[0].Content != "Полный текст" || !posts[0].FullText {
+		t.Errorf("Storage.AddPosts() post = %+v, want stored full text", posts[0])
+	}
+	_, err = st.Revisions(context.Background(), posts[0].ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.Revisions() error = %v, want %v", err, storage.ErrNotFound)
+	}
+}
+
+func TestStorage_Tags(t *testing.T) {
+
+	dbName = "testDB"
+	colName = "testCollection"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	err = st.trun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = st.Tags(context.Background())
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.Tags() error = %v, want %v", err, storage.ErrNotFound)
+	}
+
+	for _, p := range posts {
+		_, err := st.addOne(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []storage.TagCount{{Tag: "go", Count: 2}, {Tag: "generics", Count: 1}}
+	got, err := st.Tags(context.Background())
+	if err != nil {
+		t.Fatalf("Storage.Tags() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Storage.Tags() = %v, want %v", got, want)
+	}
+}
+
+func TestStorage_clusters(t *testing.T) {
+
+	dbName = "testDB"
+	colName = "testCollection"
+
+	opts := setOpts(path, "admin", os.Getenv("MONGO_DB_PASSWD"))
+	st, err := new(opts)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer st.Close()
+
+	err = st.trun()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := minhash.Signature("Go 1.23 is released", "The Go team has released Go 1.23 with range-over-func iterators.")
+	near := minhash.Signature("Go 1.23 arrives with iterators", "Version 1.23 of the Go programming language adds range over function iterators.")
+	other := minhash.Signature("Kubernetes 1.31 released", "Kubernetes v1.31 ships 45 enhancements.")
+	now := time.Now()
+
+	// Второй пост относится к сюжету первого, третий начинает свой.
+	ch := make(chan storage.Post, 3)
+	ch <- storage.Post{SourceID: "1", Title: "Go 1.23", Link: "https://go.dev/1", PubTime: now.Add(-time.Hour), MinHash: fp}
+	ch <- storage.Post{SourceID: "2", Title: "Go 1.23 released", Link: "https://habr.com/2", PubTime: now, MinHash: near}
+	ch <- storage.Post{SourceID: "3", Title: "Kubernetes 1.31", Link: "https://k8s.io/3", PubTime: now, MinHash: other}
+	close(ch)
+	n, err := st.AddPosts(context.Background(), ch)
+	if err != nil || n != 3 {
+		t.Fatalf("Storage.AddPosts() = %v, %v, want 3", n, err)
+	}
+
+	op := &storage.Options{Collapse: true}
+	num, err := st.Count(context.Background(), op)
+	if err != nil || num != 2 {
+		t.Errorf("Storage.Count() = %v, %v, want 2", num, err)
+	}
+
+	got, err := st.Posts(context.Background(), op)
+	if err != nil {
+		t.Fatalf("Storage.Posts() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Storage.Posts() = %v, want 2 posts", got)
+	}
+	// Сюжеты отсортированы по дате публикации первого поста.
+	if got[0].Link != "https://k8s.io/3" || len(got[0].AlsoCoveredBy) != 0 {
+		t.Errorf("Storage.Posts() = %+v, want single post", got[0])
+	}
+	if got[1].Link != "https://go.dev/1" || len(got[1].AlsoCoveredBy) != 1 || got[1].AlsoCoveredBy[0].Link != "https://habr.com/2" {
+		t.Errorf("Storage.Posts() = %+v, want post covered by source 2", got[1])
+	}
+
+	// Пагинация считается по сюжетам.
+	op = &storage.Options{Collapse: true, Count: 1, Offset: 1}
+	got, err = st.Posts(context.Background(), op)
+	if err != nil || len(got) != 1 || got[0].Link != "https://go.dev/1" || len(got[0].AlsoCoveredBy) != 1 {
+		t.Errorf("Storage.Posts() = %+v, %v, want post covered by source 2", got, err)
+	}
+
+	// Фильтры проверяются для первого поста сюжета.
+	op = &storage.Options{Collapse: true, SourceID: "2"}
+	if _, err = st.Posts(context.Background(), op); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Storage.Posts() error = %v, want %v", err, storage.ErrNotFound)
+	}
+}
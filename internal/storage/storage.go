@@ -3,8 +3,13 @@
 package storage
 
 import (
+	"GoNews/internal/minhash"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -12,15 +17,290 @@ import (
 var (
 	ErrNotFound    = errors.New("post not found")
 	ErrIncorrectId = errors.New("incorrect id")
+	ErrDuplicate   = errors.New("already exists")
 )
 
+// Source - структура источника постов (RSS ленты) с метаданными издания.
+// Источник однозначно определяется адресом ленты.
+type Source struct {
+	ID          string    `json:"id" bson:"_id"`
+	URL         string    `json:"url" bson:"url"`
+	Title       string    `json:"title" bson:"title"`
+	Link        string    `json:"link" bson:"link"`
+	Description string    `json:"description" bson:"description"`
+	Language    string    `json:"language" bson:"language"`
+	Image       string    `json:"image" bson:"image"`
+	Updated     time.Time `json:"updated" bson:"updated"`
+}
+
+// Feed - настройки опроса RSS ленты. Нулевые Period и Timeout означают
+// значения по умолчанию парсера. Group - путь папки ленты вида
+// "Новости/Наука", используется при импорте и экспорте OPML. FullText
+// включает загрузку полного текста статей по ссылкам постов. Учетные
+// данные не передаются в API. Лента однозначно определяется адресом.
+type Feed struct {
+	ID       string            `json:"id" bson:"_id"`
+	URL      string            `json:"url" bson:"url"`
+	Name     string            `json:"name" bson:"name"`
+	Group    string            `json:"group,omitempty" bson:"group"`
+	Period   time.Duration     `json:"period" bson:"period"`
+	Timeout  time.Duration     `json:"timeout" bson:"timeout"`
+	Enabled  bool              `json:"enabled" bson:"enabled"`
+	FullText bool              `json:"fullText" bson:"fullText"`
+	Headers  map[string]string `json:"headers,omitempty" bson:"headers"`
+	Auth     FeedAuth          `json:"-" bson:"auth"`
+}
+
+// FeedAuth - учетные данные для доступа к ленте. Если указан Token,
+// используется схема Bearer, если Username - схема Basic. Значения
+// могут ссылаться на переменные окружения в виде ${NAME}, ссылки
+// раскрываются только при выполнении запроса.
+type FeedAuth struct {
+	Username string `json:"username" bson:"username"`
+	Password string `json:"password" bson:"password"`
+	Token    string `json:"token" bson:"token"`
+}
+
+// Состояния работоспособности RSS ленты.
+const (
+	// HealthHealthy - последний опрос ленты прошел успешно.
+	HealthHealthy = "healthy"
+	// HealthDegraded - несколько последних опросов завершились ошибкой.
+	HealthDegraded = "degraded"
+	// HealthFailing - лента недоступна продолжительное время.
+	HealthFailing = "failing"
+	// HealthDisabled - лента выключена в настройках и не опрашивается.
+	HealthDisabled = "disabled"
+)
+
+// FeedState - состояние опроса RSS ленты. Значения заголовков ETag
+// и Last-Modified из последнего успешного ответа передаются в следующем
+// запросе, чтобы ресурс мог ответить 304 Not Modified без тела. Поля
+// Health, Failures и LastError описывают работоспособность ленты,
+// NextAttempt - время следующего опроса с учетом отсрочки после ошибок.
+// NotModified - количество ответов 304 Not Modified от ленты.
+// Hub и Topic - адрес хаба WebSub и адрес ленты для подписки из
+// последнего успешного ответа. Состояние однозначно определяется
+// адресом ленты.
+type FeedState struct {
+	URL          string    `json:"url" bson:"_id"`
+	ETag         string    `json:"etag" bson:"etag"`
+	LastModified string    `json:"lastModified" bson:"lastModified"`
+	Health       string    `json:"health" bson:"health"`
+	Failures     int       `json:"failures" bson:"failures"`
+	LastError    string    `json:"lastError" bson:"lastError"`
+	LastSuccess  time.Time `json:"lastSuccess" bson:"lastSuccess"`
+	LastFailure  time.Time `json:"lastFailure" bson:"lastFailure"`
+	NextAttempt  time.Time `json:"nextAttempt" bson:"nextAttempt"`
+	NotModified  int       `json:"notModified" bson:"notModified"`
+	Hub          string    `json:"hub,omitempty" bson:"hub"`
+	Topic        string    `json:"topic,omitempty" bson:"topic"`
+	Updated      time.Time `json:"updated" bson:"updated"`
+}
+
+// Состояния подписки WebSub.
+const (
+	// SubscriptionPending - запрос подписки отправлен в хаб, хаб еще
+	// не подтвердил подписку.
+	SubscriptionPending = "pending"
+	// SubscriptionActive - подписка подтверждена, хаб доставляет
+	// обновления ленты до окончания аренды.
+	SubscriptionActive = "active"
+	// SubscriptionDenied - хаб отказал в подписке.
+	SubscriptionDenied = "denied"
+	// SubscriptionStale - хаб перестал доставлять обновления, лента
+	// снова опрашивается с обычным периодом.
+	SubscriptionStale = "stale"
+)
+
+// Subscription - подписка WebSub на обновления RSS ленты. Secret - ключ
+// для проверки подписи доставленных хабом обновлений, в API не
+// передается. Подписка однозначно определяется ID ленты.
+type Subscription struct {
+	FeedID       string    `json:"feedId" bson:"_id"`
+	Hub          string    `json:"hub" bson:"hub"`
+	Topic        string    `json:"topic" bson:"topic"`
+	Secret       string    `json:"-" bson:"secret"`
+	State        string    `json:"state" bson:"state"`
+	LeaseSeconds int       `json:"leaseSeconds" bson:"leaseSeconds"`
+	LeaseExpires time.Time `json:"leaseExpires" bson:"leaseExpires"`
+	Requested    time.Time `json:"requested" bson:"requested"`
+	LastDelivery time.Time `json:"lastDelivery" bson:"lastDelivery"`
+	Updated      time.Time `json:"updated" bson:"updated"`
+}
+
 // Post - структура поста из RSS ленты для работы с БД.
 type Post struct {
-	ID      string    `json:"id" bson:"_id"`
-	Title   string    `json:"title" bson:"title"`
-	Content string    `json:"content" bson:"content"`
+	ID       string `json:"id" bson:"_id"`
+	SourceID string `json:"sourceId" bson:"sourceId"`
+	Title    string `json:"title" bson:"title"`
+
+	// Content - текст поста без разметки.
+	Content string `json:"content" bson:"content"`
+
+	// ContentHTML - очищенный HTML поста с абзацами, списками, кодом
+	// и ссылками, пустой, если источник не передал разметку.
+	ContentHTML string `json:"contentHtml,omitempty" bson:"contentHtml"`
+
 	PubTime time.Time `json:"pubTime" bson:"pubTime"`
-	Link    string    `json:"link" bson:"link"`
+
+	// PubTimeGuessed сообщает, что дату публикации не удалось разобрать
+	// и вместо нее записано время получения поста.
+	PubTimeGuessed bool `json:"pubTimeGuessed" bson:"pubTimeGuessed"`
+
+	Link       string      `json:"link" bson:"link"`
+	GUID       string      `json:"guid" bson:"guid"`
+	Author     string      `json:"author" bson:"author"`
+	Categories []string    `json:"categories" bson:"categories"`
+	Enclosures []Enclosure `json:"enclosures" bson:"enclosures"`
+
+	// Image - адрес главного изображения поста для карточек
+	// в клиентских приложениях, пустой, если изображения нет.
+	Image string `json:"image" bson:"image"`
+
+	// Lang - код языка поста ISO 639, например "ru" или "en", пустой,
+	// если язык определить не удалось.
+	Lang string `json:"lang" bson:"lang"`
+
+	// Tags - теги, назначенные посту правилами разметки.
+	Tags []string `json:"tags" bson:"tags"`
+
+	// FullText сообщает, что содержание поста - полный текст статьи,
+	// загруженный по ссылке.
+	FullText bool `json:"fullText" bson:"fullText"`
+
+	// FullTextFailed сообщает, что полный текст статьи загрузить
+	// не удалось и содержание взято из ленты. Такое содержание
+	// не заменяет записанный полный текст. В БД не записывается.
+	FullTextFailed bool `json:"-" bson:"-"`
+
+	// Teaser - хеш ContentHash поста в том виде, в котором он получен
+	// из ленты, до замены полным текстом статьи. По нему статья
+	// не загружается заново, пока пост в ленте не изменится.
+	Teaser string `json:"-" bson:"teaser"`
+
+	// MinHash - сигнатура minhash.Signature заголовка и текста поста,
+	// по ней находятся посты разных источников об одном и том же.
+	MinHash []uint32 `json:"-" bson:"minHash"`
+
+	// Cluster - ID первого поста сюжета, к которому относится пост,
+	// у первого поста сюжета совпадает с его ID.
+	Cluster string `json:"cluster,omitempty" bson:"cluster"`
+
+	// AlsoCoveredBy - другие посты сюжета, заполняется только при
+	// свертке сюжетов и в БД не записывается.
+	AlsoCoveredBy []Coverage `json:"alsoCoveredBy,omitempty" bson:"-"`
+
+	// Updated - время последнего изменения поста источником, нулевое,
+	// если пост не менялся.
+	Updated time.Time `json:"updated" bson:"updated"`
+}
+
+// Coverage - краткие сведения о посте другого источника из того же
+// сюжета.
+type Coverage struct {
+	ID       string    `json:"id" bson:"_id"`
+	SourceID string    `json:"sourceId" bson:"sourceId"`
+	Title    string    `json:"title" bson:"title"`
+	Link     string    `json:"link" bson:"link"`
+	PubTime  time.Time `json:"pubTime" bson:"pubTime"`
+}
+
+// ClusterWindow - максимальная разница во времени публикации
+// постов, относящихся к одному сюжету. Все реализации DB
+// ищут сюжет нового поста среди постов других источников в этих
+// пределах.
+const ClusterWindow = 72 * time.Hour
+
+// Revision - предыдущая версия поста, сохраненная при его изменении
+// источником. Created - время, с которого действовала версия: время
+// предыдущего изменения поста или время публикации. Replaced - время,
+// когда версия была заменена.
+type Revision struct {
+	ID       string    `json:"id" bson:"_id"`
+	PostID   string    `json:"postId" bson:"postId"`
+	Title    string    `json:"title" bson:"title"`
+	Content  string    `json:"content" bson:"content"`
+	Link     string    `json:"link" bson:"link"`
+	Created  time.Time `json:"created" bson:"created"`
+	Replaced time.Time `json:"replaced" bson:"replaced"`
+}
+
+// DedupKey возвращает ключ, по которому пост считается повтором уже
+// записанного поста того же источника: GUID, если он указан, иначе
+// ссылку на пост LinkKey, иначе хеш заголовка и содержания. Ключ
+// не зависит от заголовка, если есть GUID или ссылка, поэтому
+// исправленный заголовок не создает новый пост. Все реализации DB
+// пропускают посты с ключом, уже записанным для того же источника.
+func DedupKey(p Post) string {
+	if guid := strings.TrimSpace(p.GUID); guid != "" {
+		return "guid:" + guid
+	}
+	if link := strings.TrimSpace(p.Link); link != "" {
+		return LinkKey(link)
+	}
+	return "hash:" + ContentHash(p)
+}
+
+// LinkKey возвращает ключ повтора поста по ссылке на него. Завершающий
+// "/" пути ссылки http и https в ключе не учитывается, поэтому посты
+// источника со ссылками "/a" и "/a/" считаются одним постом. Сама
+// ссылка записывается без изменений.
+func LinkKey(link string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Path) > 1 && strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+		link = u.String()
+	}
+	return "link:" + link
+}
+
+// ContentHash возвращает хеш заголовка и содержания поста. Пост
+// с известным ключом повтора DedupKey, хеш которого отличается
+// от записанного, считается измененным источником.
+func ContentHash(p Post) string {
+	h := sha256.Sum256([]byte(p.Title + "\x00" + p.Content))
+	return hex.EncodeToString(h[:])
+}
+
+// SameStory сообщает, что пост p относится к сюжету поста other:
+// посты опубликованы разными источниками в пределах ClusterWindow,
+// а их сигнатуры MinHash похожи.
+func SameStory(p, other Post) bool {
+	if p.SourceID == other.SourceID {
+		return false
+	}
+	if d := p.PubTime.Sub(other.PubTime); d > ClusterWindow || d < -ClusterWindow {
+		return false
+	}
+	return minhash.Similar(p.MinHash, other.MinHash)
+}
+
+// KeepFullText возвращает пост p, полученный из ленты повторно,
+// с полным текстом записанного поста old, если загрузить статью заново
+// не удалось. Иначе возвращает p без изменений. Все реализации DB
+// сравнивают с записанным постом результат KeepFullText, поэтому
+// неудачная загрузка не создает версию поста.
+func KeepFullText(old, p Post) Post {
+	if !p.FullTextFailed || !old.FullText {
+		return p
+	}
+	p.Content, p.ContentHTML = old.Content, old.ContentHTML
+	p.FullText, p.FullTextFailed = true, false
+	p.Teaser = old.Teaser
+	if p.Image == "" {
+		p.Image = old.Image
+	}
+	return p
+}
+
+// Enclosure - структура вложения поста (изображения, аудио или видео).
+type Enclosure struct {
+	URL    string `json:"url" bson:"url"`
+	Type   string `json:"type" bson:"type"`
+	Length int64  `json:"length" bson:"length"`
 }
 
 // TextSearch - структура запроса для текстового поиска в БД
@@ -34,6 +314,26 @@ type Options struct {
 
 	// Offset - число постов на сдвиг в пагинации.
 	Offset int
+
+	// SourceID - идентификатор источника для фильтрации постов.
+	SourceID string
+
+	// Lang - код языка для фильтрации постов.
+	Lang string
+
+	// Tag - тег для фильтрации постов.
+	Tag string
+
+	// Collapse - свертка сюжетов: из постов одного сюжета возвращается
+	// только первый, остальные перечисляются в его AlsoCoveredBy.
+	// Остальные условия поиска проверяются только для первого поста.
+	Collapse bool
+}
+
+// TagCount - тег постов и количество постов с этим тегом.
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
 }
 
 // Interface - интерфейс хранилища постов из RSS лент.
@@ -44,5 +344,22 @@ type DB interface {
 	Posts(ctx context.Context, op ...*Options) ([]Post, error)
 	Count(ctx context.Context, q ...*Options) (int64, error)
 	PostById(ctx context.Context, id string) (Post, error)
+	PostByKey(ctx context.Context, sourceID, key string) (Post, error)
+	Revisions(ctx context.Context, postID string) ([]Revision, error)
+	Tags(ctx context.Context) ([]TagCount, error)
+	AddSource(ctx context.Context, src Source) (string, error)
+	Sources(ctx context.Context) ([]Source, error)
+	FeedState(ctx context.Context, url string) (FeedState, error)
+	SetFeedState(ctx context.Context, st FeedState) error
+	FeedStates(ctx context.Context) ([]FeedState, error)
+	Feeds(ctx context.Context) ([]Feed, error)
+	FeedsSeeded(ctx context.Context) (bool, error)
+	SetFeedsSeeded(ctx context.Context) error
+	FeedById(ctx context.Context, id string) (Feed, error)
+	AddFeed(ctx context.Context, feed Feed) (string, error)
+	UpdateFeed(ctx context.Context, feed Feed) error
+	RemoveFeed(ctx context.Context, id string) error
+	Subscription(ctx context.Context, feedID string) (Subscription, error)
+	SetSubscription(ctx context.Context, sub Subscription) error
 	Close() error
 }
@@ -0,0 +1,141 @@
+// Пакет содержит основную структуру Post для работы с постом из RSS лент и интерфейс
+// для работы с любой реализацией базы данных, удовлетворяющей этому интерфейсу.
+package storage
+
+import (
+	"GoNews/internal/minhash"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    Post
+		b    Post
+		want bool
+	}{
+		{
+			// Исправленный заголовок не меняет ключ.
+			name: "GUID",
+			a:    Post{Title: "Go 1.23", GUID: "https://go.dev/blog/go1.23", Link: "https://go.dev/blog/go1.23"},
+			b:    Post{Title: "Go 1.23 is released", GUID: "https://go.dev/blog/go1.23", Link: "https://go.dev/blog/go1.23?utm_source=rss"},
+			want: true,
+		},
+		{
+			name: "Different_GUID",
+			a:    Post{Title: "Go Weekly", GUID: "1"},
+			b:    Post{Title: "Go Weekly", GUID: "2"},
+			want: false,
+		},
+		{
+			name: "Link",
+			a:    Post{Title: "Go 1.23", Link: "https://go.dev/blog/go1.23"},
+			b:    Post{Title: "Go 1.23 is released", Link: " https://go.dev/blog/go1.23 "},
+			want: true,
+		},
+		{
+			// Завершающий "/" пути в ключе не учитывается.
+			name: "Link_Trailing_Slash",
+			a:    Post{Link: "https://habr.com/ru/articles/831252/?id=1"},
+			b:    Post{Link: "https://habr.com/ru/articles/831252?id=1"},
+			want: true,
+		},
+		{
+			name: "Different_Link",
+			a:    Post{Link: "https://example.com/"},
+			b:    Post{Link: "https://example.com/a/"},
+			want: false,
+		},
+		{
+			name: "Content",
+			a:    Post{Title: "Go Weekly", Content: "Issue 1"},
+			b:    Post{Title: "Go Weekly", Content: "Issue 1"},
+			want: true,
+		},
+		{
+			name: "Different_Content",
+			a:    Post{Title: "Go Weekly", Content: "Issue 1"},
+			b:    Post{Title: "Go Weekly", Content: "Issue 2"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, b := DedupKey(tt.a), DedupKey(tt.b)
+			if (a == b) != tt.want {
+				t.Errorf("DedupKey() = %v, %v, want equal %v", a, b, tt.want)
+			}
+		})
+	}
+
+	if key := DedupKey(Post{Title: "Go Weekly"}); !strings.HasPrefix(key, "hash:") {
+		t.Errorf("DedupKey() = %v, want content hash", key)
+	}
+}
+
+func TestSameStory(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	fp := minhash.Signature("Go 1.23 is released", "The Go team has released Go 1.23 with range-over-func iterators.")
+	near := minhash.Signature("Go 1.23 arrives with iterators", "Version 1.23 of the Go programming language adds range over function iterators.")
+	far := minhash.Signature("Kubernetes 1.31 released", "Kubernetes v1.31 ships 45 enhancements.")
+
+	tests := []struct {
+		name string
+		a    Post
+		b    Post
+		want bool
+	}{
+		{
+			name: "OK",
+			a:    Post{SourceID: "1", PubTime: now, MinHash: fp},
+			b:    Post{SourceID: "2", PubTime: now.Add(-time.Hour), MinHash: near},
+			want: true,
+		},
+		{
+			name: "Same_source",
+			a:    Post{SourceID: "1", PubTime: now, MinHash: fp},
+			b:    Post{SourceID: "1", PubTime: now, MinHash: fp},
+			want: false,
+		},
+		{
+			name: "Outside_window",
+			a:    Post{SourceID: "1", PubTime: now, MinHash: fp},
+			b:    Post{SourceID: "2", PubTime: now.Add(ClusterWindow + time.Hour), MinHash: fp},
+			want: false,
+		},
+		{
+			name: "Different_text",
+			a:    Post{SourceID: "1", PubTime: now, MinHash: fp},
+			b:    Post{SourceID: "2", PubTime: now, MinHash: far},
+			want: false,
+		},
+		{
+			// Посты, записанные до появления сигнатур.
+			name: "No_signature",
+			a:    Post{SourceID: "1", PubTime: now},
+			b:    Post{SourceID: "2", PubTime: now},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := SameStory(tt.a, tt.b); got != tt.want {
+				t.Errorf("SameStory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
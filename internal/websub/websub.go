@@ -0,0 +1,272 @@
+// Пакет подписчика WebSub (PubSubHubbub) на обновления RSS лент.
+package websub
+
+import (
+	"GoNews/internal/storage"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBadStatus    = errors.New("bad hub response status")
+	ErrBadRequest   = errors.New("incorrect verification request")
+	ErrUnknownTopic = errors.New("unknown subscription topic")
+	ErrBadSignature = errors.New("invalid payload signature")
+)
+
+// Режимы запросов хаба для подтверждения подписки.
+const (
+	ModeSubscribe   = "subscribe"
+	ModeUnsubscribe = "unsubscribe"
+	ModeDenied      = "denied"
+)
+
+// CallbackPath - путь обработчика запросов хаба на сервере, к которому
+// добавляется ID ленты.
+const CallbackPath = "/websub/"
+
+// leaseSeconds - запрашиваемый у хаба срок аренды подписки в секундах.
+// Хаб может назначить другой срок при подтверждении подписки.
+const leaseSeconds = 10 * 24 * 60 * 60
+
+// Subscriber - подписчик WebSub. Подписки хранятся в БД, чтобы проверять
+// запросы хаба и после перезапуска сервиса.
+type Subscriber struct {
+	client   *http.Client
+	callback string
+	storage  storage.DB
+}
+
+// New - конструктор подписчика. callback - внешний адрес сервера,
+// по которому хаб отправляет запросы, например "https://news.example.com".
+func New(client *http.Client, callback string, st storage.DB) *Subscriber {
+	return &Subscriber{
+		client:   client,
+		callback: strings.TrimSuffix(callback, "/"),
+		storage:  st,
+	}
+}
+
+// Callback возвращает адрес обработчика запросов хаба для ленты.
+func (s *Subscriber) Callback(feedID string) string {
+	return s.callback + CallbackPath + url.PathEscape(feedID)
+}
+
+// Subscribe записывает подписку в БД и отправляет запрос подписки в хаб.
+// Для новой подписки создается ключ подписи, при продлении активной
+// подписки ключ и состояние сохраняются, чтобы доставка обновлений
+// не прерывалась. Хаб подтверждает подписку отдельным запросом,
+// который обрабатывает Verify. Возвращает записанную подписку.
+func (s *Subscriber) Subscribe(ctx context.Context, sub storage.Subscription) (storage.Subscription, error) {
+	const operation = "websub.Subscribe"
+
+	if sub.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return sub, fmt.Errorf("%s: %w", operation, err)
+		}
+		sub.Secret = secret
+	}
+	if sub.State != storage.SubscriptionActive {
+		sub.State = storage.SubscriptionPending
+	}
+	sub.Requested = time.Now()
+
+	// Хаб может подтвердить подписку еще до ответа на запрос, поэтому
+	// подписка записывается в БД заранее.
+	err := s.storage.SetSubscription(ctx, sub)
+	if err != nil {
+		return sub, fmt.Errorf("%s: %w", operation, err)
+	}
+
+	form := url.Values{
+		"hub.mode":          {ModeSubscribe},
+		"hub.topic":         {sub.Topic},
+		"hub.callback":      {s.Callback(sub.FeedID)},
+		"hub.secret":        {sub.Secret},
+		"hub.lease_seconds": {strconv.Itoa(leaseSeconds)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return sub, fmt.Errorf("%s: %w", operation, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return sub, fmt.Errorf("%s: %w", operation, err)
+	}
+	defer func() {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return sub, fmt.Errorf("%s: %w: %s", operation, ErrBadStatus, resp.Status)
+	}
+	return sub, nil
+}
+
+// Verify обрабатывает запрос хаба на подтверждение подписки или отписки
+// ленты и возвращает значение hub.challenge для ответа хабу. Подписка
+// подтверждается, только если она была запрошена для того же адреса
+// ленты, отписка - только если активной подписки на ленту нет. Отказ
+// хаба в подписке отмечается в БД, в этом случае возвращается пустая
+// строка. На запрос, который не нужно подтверждать, возвращает
+// ErrUnknownTopic или storage.ErrNotFound.
+func (s *Subscriber) Verify(ctx context.Context, feedID string, q url.Values) (string, error) {
+	const operation = "websub.Verify"
+
+	mode := q.Get("hub.mode")
+	topic := q.Get("hub.topic")
+	challenge := q.Get("hub.challenge")
+	if mode != ModeDenied && challenge == "" {
+		return "", fmt.Errorf("%s: %w", operation, ErrBadRequest)
+	}
+
+	sub, err := s.storage.Subscription(ctx, feedID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return "", fmt.Errorf("%s: %w", operation, err)
+	}
+	found := err == nil && sub.Topic == topic
+
+	switch mode {
+	case ModeSubscribe:
+		if !found || (sub.State != storage.SubscriptionPending && sub.State != storage.SubscriptionActive) {
+			return "", fmt.Errorf("%s: %w", operation, ErrUnknownTopic)
+		}
+		lease, err := strconv.Atoi(q.Get("hub.lease_seconds"))
+		if err != nil || lease <= 0 {
+			lease = leaseSeconds
+		}
+		sub.State = storage.SubscriptionActive
+		sub.LeaseSeconds = lease
+		sub.LeaseExpires = time.Now().Add(time.Duration(lease) * time.Second)
+
+	case ModeUnsubscribe:
+		if found && sub.State == storage.SubscriptionActive {
+			return "", fmt.Errorf("%s: %w", operation, ErrUnknownTopic)
+		}
+		return challenge, nil
+
+	case ModeDenied:
+		if !found {
+			return "", fmt.Errorf("%s: %w", operation, ErrUnknownTopic)
+		}
+		sub.State = storage.SubscriptionDenied
+		challenge = ""
+
+	default:
+		return "", fmt.Errorf("%s: %w", operation, ErrBadRequest)
+	}
+
+	err = s.storage.SetSubscription(ctx, sub)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", operation, err)
+	}
+	return challenge, nil
+}
+
+// Check проверяет подпись обновления ленты из заголовка X-Hub-Signature
+// ключом подписки и возвращает подписку. Обновление без подписи или
+// с неверной подписью отклоняется с ошибкой ErrBadSignature.
+func (s *Subscriber) Check(ctx context.Context, feedID, signature string, body []byte) (storage.Subscription, error) {
+	const operation = "websub.Check"
+
+	sub, err := s.storage.Subscription(ctx, feedID)
+	if err != nil {
+		return sub, fmt.Errorf("%s: %w", operation, err)
+	}
+	if sub.Secret == "" || !validSignature(sub.Secret, signature, body) {
+		return sub, fmt.Errorf("%s: %w", operation, ErrBadSignature)
+	}
+	return sub, nil
+}
+
+// Links возвращает адреса хаба и самой ленты из ссылок rel="hub"
+// и rel="self" заголовка Link ответа. Хаб из заголовка имеет приоритет
+// над ссылками внутри ленты.
+func Links(h http.Header) (hub, self string) {
+	for _, v := range h.Values("Link") {
+		for _, link := range strings.Split(v, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			target = strings.TrimSpace(target)
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = strings.Trim(target, "<>")
+
+			for _, p := range strings.Split(params, ";") {
+				key, val, _ := strings.Cut(strings.TrimSpace(p), "=")
+				if !strings.EqualFold(key, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					switch {
+					case strings.EqualFold(rel, "hub") && hub == "":
+						hub = target
+					case strings.EqualFold(rel, "self") && self == "":
+						self = target
+					}
+				}
+			}
+		}
+	}
+	return hub, self
+}
+
+// validSignature проверяет подпись тела обновления в формате
+// "method=signature", где method - одна из хеш-функций sha1, sha256,
+// sha384 или sha512, а signature - HMAC тела ключом подписки в hex.
+func validSignature(secret, signature string, body []byte) bool {
+	method, sig, ok := strings.Cut(signature, "=")
+	if !ok {
+		return false
+	}
+
+	var h func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	case "sha384":
+		h = sha512.New384
+	case "sha512":
+		h = sha512.New
+	default:
+		return false
+	}
+
+	want, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+// newSecret создает случайный ключ подписи обновлений.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,360 @@
+// Пакет подписчика WebSub (PubSubHubbub) на обновления RSS лент.
+package websub
+
+import (
+	"GoNews/internal/logger"
+	"GoNews/internal/mocks"
+	"GoNews/internal/storage"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testHub   = "https://pubsubhubbub.appspot.com/"
+	testTopic = "https://go.dev/blog/feed.atom"
+)
+
+// sign возвращает подпись тела обновления в формате заголовка
+// X-Hub-Signature.
+func sign(method string, h func() hash.Hash, secret string, body []byte) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return method + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Test_validSignature(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`<feed xmlns="http://www.w3.org/2005/Atom"></feed>`)
+
+	tests := []struct {
+		name      string
+		signature string
+		want      bool
+	}{
+		{
+			name:      "SHA1",
+			signature: sign("sha1", sha1.New, "secret", body),
+			want:      true,
+		},
+		{
+			name:      "SHA256",
+			signature: sign("sha256", sha256.New, "secret", body),
+			want:      true,
+		},
+		{
+			name:      "SHA512",
+			signature: sign("sha512", sha512.New, "secret", body),
+			want:      true,
+		},
+		{
+			name:      "Wrong_Secret",
+			signature: sign("sha256", sha256.New, "other", body),
+			want:      false,
+		},
+		{
+			name:      "Wrong_Method",
+			signature: "sha256=" + strings.TrimPrefix(sign("sha1", sha1.New, "secret", body), "sha1="),
+			want:      false,
+		},
+		{
+			name:      "Unknown_Method",
+			signature: "md5=0123456789abcdef",
+			want:      false,
+		},
+		{
+			name:      "No_Signature",
+			signature: "",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := validSignature("secret", tt.signature, body); got != tt.want {
+				t.Errorf("validSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+
+	h := make(http.Header)
+	h.Add("Link", `<https://go.dev/blog/>; rel="alternate"`)
+	h.Add("Link", `<https://pubsubhubbub.appspot.com/>; rel="hub", <https://go.dev/blog/feed.atom>; rel="self"`)
+	h.Add("Link", `<https://other-hub.example.com/>; rel=hub`)
+
+	hub, self := Links(h)
+	if hub != testHub || self != testTopic {
+		t.Errorf("Links() = %v, %v, want %v, %v", hub, self, testHub, testTopic)
+	}
+
+	hub, self = Links(make(http.Header))
+	if hub != "" || self != "" {
+		t.Errorf("Links() = %v, %v, want empty", hub, self)
+	}
+}
+
+func TestSubscriber_Subscribe(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		sub       storage.Subscription
+		status    int
+		wantState string
+		wantErr   error
+	}{
+		{
+			name:      "New",
+			sub:       storage.Subscription{FeedID: "1", Hub: testHub, Topic: testTopic},
+			status:    http.StatusAccepted,
+			wantState: storage.SubscriptionPending,
+		},
+		{
+			// При продлении подписки ключ и состояние не меняются.
+			name:      "Renew",
+			sub:       storage.Subscription{FeedID: "1", Hub: testHub, Topic: testTopic, Secret: "secret", State: storage.SubscriptionActive},
+			status:    http.StatusAccepted,
+			wantState: storage.SubscriptionActive,
+		},
+		{
+			name:      "Bad_Status",
+			sub:       storage.Subscription{FeedID: "1", Hub: testHub, Topic: testTopic, State: storage.SubscriptionStale},
+			status:    http.StatusBadRequest,
+			wantState: storage.SubscriptionPending,
+			wantErr:   ErrBadStatus,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stMock := mocks.NewDB(t)
+			stMock.
+				On("SetSubscription", mock.Anything, mock.AnythingOfType("storage.Subscription")).
+				Return(nil).
+				Once()
+
+			var form url.Values
+			rtMock := mocks.NewRoundTripper(t)
+			rtMock.
+				On("RoundTrip", mock.AnythingOfType("*http.Request")).
+				Return(func(req *http.Request) (*http.Response, error) {
+					req.ParseForm()
+					form = req.PostForm
+					return &http.Response{
+						StatusCode: tt.status,
+						Status:     http.StatusText(tt.status),
+						Body:       io.NopCloser(strings.NewReader("")),
+						Header:     make(http.Header),
+						Request:    req,
+					}, nil
+				}).
+				Once()
+
+			s := New(&http.Client{Transport: rtMock}, "https://news.example.com/", stMock)
+			got, err := s.Subscribe(context.Background(), tt.sub)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Subscriber.Subscribe() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.State != tt.wantState || got.Secret == "" || got.Requested.IsZero() {
+				t.Errorf("Subscriber.Subscribe() = %+v, want state %v with secret", got, tt.wantState)
+			}
+			if tt.sub.Secret != "" && got.Secret != tt.sub.Secret {
+				t.Errorf("Subscriber.Subscribe() secret = %v, want %v", got.Secret, tt.sub.Secret)
+			}
+
+			if form.Get("hub.mode") != ModeSubscribe || form.Get("hub.topic") != testTopic ||
+				form.Get("hub.callback") != "https://news.example.com/websub/1" || form.Get("hub.secret") != got.Secret {
+				t.Errorf("Subscriber.Subscribe() request = %v", form)
+			}
+		})
+	}
+}
+
+func TestSubscriber_Verify(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	pending := storage.Subscription{FeedID: "1", Hub: testHub, Topic: testTopic, Secret: "secret", State: storage.SubscriptionPending}
+	active := pending
+	active.State = storage.SubscriptionActive
+
+	tests := []struct {
+		name      string
+		query     url.Values
+		sub       storage.Subscription
+		subErr    error
+		want      string
+		wantState string
+		wantErr   error
+	}{
+		{
+			name: "Subscribe",
+			query: url.Values{
+				"hub.mode":          {ModeSubscribe},
+				"hub.topic":         {testTopic},
+				"hub.challenge":     {"challenge"},
+				"hub.lease_seconds": {"3600"},
+			},
+			sub:       pending,
+			want:      "challenge",
+			wantState: storage.SubscriptionActive,
+		},
+		{
+			name: "Subscribe_Unknown_Topic",
+			query: url.Values{
+				"hub.mode":      {ModeSubscribe},
+				"hub.topic":     {"https://go.dev/other.atom"},
+				"hub.challenge": {"challenge"},
+			},
+			sub:     pending,
+			wantErr: ErrUnknownTopic,
+		},
+		{
+			name: "Subscribe_Not_Requested",
+			query: url.Values{
+				"hub.mode":      {ModeSubscribe},
+				"hub.topic":     {testTopic},
+				"hub.challenge": {"challenge"},
+			},
+			subErr:  storage.ErrNotFound,
+			wantErr: ErrUnknownTopic,
+		},
+		{
+			name: "Unsubscribe_Removed_Feed",
+			query: url.Values{
+				"hub.mode":      {ModeUnsubscribe},
+				"hub.topic":     {testTopic},
+				"hub.challenge": {"challenge"},
+			},
+			subErr: storage.ErrNotFound,
+			want:   "challenge",
+		},
+		{
+			name: "Unsubscribe_Active",
+			query: url.Values{
+				"hub.mode":      {ModeUnsubscribe},
+				"hub.topic":     {testTopic},
+				"hub.challenge": {"challenge"},
+			},
+			sub:     active,
+			wantErr: ErrUnknownTopic,
+		},
+		{
+			name: "Denied",
+			query: url.Values{
+				"hub.mode":   {ModeDenied},
+				"hub.topic":  {testTopic},
+				"hub.reason": {"topic not found"},
+			},
+			sub:       pending,
+			wantState: storage.SubscriptionDenied,
+		},
+		{
+			name: "No_Challenge",
+			query: url.Values{
+				"hub.mode":  {ModeSubscribe},
+				"hub.topic": {testTopic},
+			},
+			wantErr: ErrBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stMock := mocks.NewDB(t)
+			if !errors.Is(tt.wantErr, ErrBadRequest) {
+				stMock.
+					On("Subscription", mock.Anything, "1").
+					Return(tt.sub, tt.subErr).
+					Once()
+			}
+			var saved storage.Subscription
+			if tt.wantState != "" {
+				stMock.
+					On("SetSubscription", mock.Anything, mock.AnythingOfType("storage.Subscription")).
+					Return(func(ctx context.Context, sub storage.Subscription) error {
+						saved = sub
+						return nil
+					}).
+					Once()
+			}
+
+			s := New(http.DefaultClient, "https://news.example.com", stMock)
+			got, err := s.Verify(context.Background(), "1", tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Subscriber.Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Subscriber.Verify() = %q, want %q", got, tt.want)
+			}
+			if saved.State != tt.wantState {
+				t.Errorf("Subscriber.Verify() state = %v, want %v", saved.State, tt.wantState)
+			}
+			if tt.wantState == storage.SubscriptionActive {
+				lease := time.Until(saved.LeaseExpires)
+				if saved.LeaseSeconds != 3600 || lease <= 0 || lease > time.Hour {
+					t.Errorf("Subscriber.Verify() lease = %d, expires in %v, want 3600", saved.LeaseSeconds, lease)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriber_Check(t *testing.T) {
+	logger.Discard()
+	t.Parallel()
+
+	body := []byte(`<feed xmlns="http://www.w3.org/2005/Atom"></feed>`)
+
+	stMock := mocks.NewDB(t)
+	stMock.
+		On("Subscription", mock.Anything, "1").
+		Return(storage.Subscription{FeedID: "1", Secret: "secret", State: storage.SubscriptionActive}, nil).
+		Twice()
+	stMock.
+		On("Subscription", mock.Anything, "2").
+		Return(storage.Subscription{}, storage.ErrNotFound).
+		Once()
+
+	s := New(http.DefaultClient, "https://news.example.com", stMock)
+	_, err := s.Check(context.Background(), "1", sign("sha256", sha256.New, "secret", body), body)
+	if err != nil {
+		t.Errorf("Subscriber.Check() error = %v", err)
+	}
+	_, err = s.Check(context.Background(), "1", sign("sha256", sha256.New, "secret", body), []byte("forged"))
+	if !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Subscriber.Check() error = %v, want %v", err, ErrBadSignature)
+	}
+	_, err = s.Check(context.Background(), "2", "", body)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Subscriber.Check() error = %v, want %v", err, storage.ErrNotFound)
+	}
+}